@@ -0,0 +1,251 @@
+package pretty
+
+import (
+	"reflect"
+)
+
+// edit is one step of an alignment of two sequences: element i of the
+// first and element j of the second are the same ('='), or element i of
+// the first was deleted ('-'), or element j of the second inserted ('+').
+type edit struct {
+	op   byte
+	i, j int
+}
+
+// align returns the shortest series of edits turning a sequence of n
+// elements into one of m, given eq to tell whether element i of the first
+// equals element j of the second.  It finds their longest common
+// subsequence so it takes time and space in proportion to n*m.
+func align(n, m int, eq func(i, j int) bool) []edit {
+	// lcs[i*(m+1)+j] is the length of the LCS of the suffixes from i and j
+	lcs := make([]int, (n+1)*(m+1))
+	same := make([]bool, n*m)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case eq(i, j):
+				same[i*m+j] = true
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j]
+			default:
+				lcs[i*(m+1)+j] = lcs[i*(m+1)+j+1]
+			}
+		}
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && same[i*m+j]:
+			edits = append(edits, edit{'=', i, j})
+			i++
+			j++
+		case i < n && (j == m || lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			edits = append(edits, edit{'-', i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+// diffElems compares the elements of av and bv, arrays or slices of the
+// same type, aligning them so that elements inserted or deleted are
+// reported as Added or Removed rather than every later element showing
+// up as Modified.  Deleted elements replaced by inserted ones are
+// compared with each other.  The common prefix and suffix are skipped
+// cheaply, if what is left between them is too big to align (see
+// Config.MaxDiffAlign) the elements are compared index by index.  When
+// only checking for equality (see equal) there is nothing to align.
+func (w differ) diffElems(av, bv reflect.Value) {
+	n, m := av.Len(), bv.Len()
+	if w.stop != nil {
+		if n != m {
+			w.change(LengthChanged, av, bv)
+			return
+		}
+		for i := 0; i < n && !*w.stop; i++ {
+			w.at(PathStep{Index: i}).diff(av.Index(i), bv.Index(i))
+		}
+		return
+	}
+	eq := func(i, j int) bool {
+		return w.at(PathStep{Index: i}).equal(av.Index(i), bv.Index(j))
+	}
+	pre := 0
+	for pre < n && pre < m && eq(pre, pre) {
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && eq(n-1-suf, m-1-suf) {
+		suf++
+	}
+	dn, dm := n-pre-suf, m-pre-suf
+	if !w.charge(dn * dm) {
+		if n != m {
+			w.change(LengthChanged, av, bv)
+			return
+		}
+		for i := pre; i < n-suf; i++ {
+			w.at(PathStep{Index: i}).diff(av.Index(i), bv.Index(i))
+		}
+		return
+	}
+	edits := align(dn, dm, func(i, j int) bool {
+		return eq(pre+i, pre+j)
+	})
+	var dels, ins []int
+	flush := func() {
+		k := 0
+		for ; k < len(dels) && k < len(ins); k++ {
+			w.at(PathStep{Index: dels[k]}).diff(av.Index(dels[k]), bv.Index(ins[k]))
+		}
+		for _, i := range dels[k:] {
+			w.at(PathStep{Index: i}).change(Removed, av.Index(i), reflect.Value{})
+		}
+		for _, j := range ins[k:] {
+			w.at(PathStep{Index: j}).change(Added, reflect.Value{}, bv.Index(j))
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+	for _, e := range edits {
+		switch e.op {
+		case '=':
+			flush()
+		case '-':
+			dels = append(dels, pre+e.i)
+		case '+':
+			ins = append(ins, pre+e.j)
+		}
+	}
+	flush()
+}
+
+// equal reports whether diff would find no differences between av and bv,
+// it stops at the first one.
+func (w differ) equal(av, bv reflect.Value) bool {
+	stop := false
+	w.stop = &stop
+	w.report = func(Change) { stop = true }
+	w.diff(av, bv)
+	return !stop
+}
+
+// charge takes the work of aligning, cost element comparisons, out of the
+// budget left for the Diff (see Config.MaxDiffAlign), returning false if
+// there isn't enough left.
+func (w differ) charge(cost int) bool {
+	if w.budget == nil {
+		return true
+	}
+	if cost > *w.budget {
+		return false
+	}
+	*w.budget -= cost
+	return true
+}
+
+// diffUnordered compares the elements of av and bv, arrays or slices of
+// the same type, as multisets (see Config.UnorderedSlices): each element
+// of av is matched with an equal one of bv, if there is one left, and
+// those left unmatched are reported as Removed or Added.  Elements of a
+// plain type are looked up in a Go map, others are compared pair by pair
+// which is charged to the alignment budget (see Config.MaxDiffAlign), if
+// that is used up they are compared in order instead.
+func (w differ) diffUnordered(av, bv reflect.Value) {
+	n, m := av.Len(), bv.Len()
+	if w.stop != nil && n != m {
+		w.change(LengthChanged, av, bv)
+		return
+	}
+	var match []int
+	if w.c.plainType(av.Type().Elem()) {
+		match = matchKeys(elems(readable(av)), elems(readable(bv)))
+	} else if w.charge(n * m) {
+		match = make([]int, n)
+		matched := make([]bool, m)
+		for i := range match {
+			match[i] = -1
+			for j := 0; j < m; j++ {
+				if !matched[j] && w.at(PathStep{Index: i}).equal(av.Index(i), bv.Index(j)) {
+					match[i], matched[j] = j, true
+					break
+				}
+			}
+		}
+	} else {
+		w.diffElems(av, bv)
+		return
+	}
+	matched := make([]bool, m)
+	for i, j := range match {
+		if j < 0 {
+			w.at(PathStep{Index: i}).change(Removed, av.Index(i), reflect.Value{})
+		} else {
+			matched[j] = true
+		}
+	}
+	for j := 0; j < m; j++ {
+		if !matched[j] {
+			w.at(PathStep{Index: j}).change(Added, reflect.Value{}, bv.Index(j))
+		}
+	}
+}
+
+// elems returns the elements of v, an array or slice.
+func elems(v reflect.Value) []reflect.Value {
+	vs := make([]reflect.Value, v.Len())
+	for i := range vs {
+		vs[i] = v.Index(i)
+	}
+	return vs
+}
+
+// unorderedType reports whether arrays or slices of type t are compared
+// as multisets.
+func (c *Config) unorderedType(t reflect.Type) bool {
+	if c.UnorderedSlices {
+		return true
+	}
+	for _, u := range c.UnorderedTypes {
+		if t == u {
+			return true
+		}
+	}
+	return false
+}
+
+// plainType reports whether values of type t are equal just when
+// keyEqual, or a Go map lookup, says they are, as they hold no pointers, interfaces, floats
+// (see Config.FloatAbsTol), values with their own comparison (see
+// Config.RegisterComparer) or struct fields Diff might skip.
+func (c *Config) plainType(t reflect.Type) bool {
+	if _, ok := c.comparers[t]; ok {
+		return false
+	}
+	if m, ok := t.MethodByName("Equal"); ok && c.UseEqual && isEqualMethod(m, t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	case reflect.Array:
+		return c.plainType(t.Elem())
+	case reflect.Struct:
+		if len(c.IgnorePaths) > 0 {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); !c.plainType(f.Type) || c.ignoreField(f) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
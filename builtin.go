@@ -0,0 +1,204 @@
+package pretty
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// builtinRenderers print well-known standard library types by their
+// meaning rather than their internal fields, they are used when no
+// Renderer has been registered for a type.
+var builtinRenderers = map[reflect.Type]Renderer{
+	reflect.TypeOf(time.Time{}):      renderTime,
+	reflect.TypeOf(time.Duration(0)): renderDuration,
+	reflect.TypeOf(big.Int{}):        renderBig,
+	reflect.TypeOf(&big.Int{}):       renderBig,
+	reflect.TypeOf(big.Float{}):      renderBig,
+	reflect.TypeOf(&big.Float{}):     renderBig,
+	reflect.TypeOf(big.Rat{}):        renderBig,
+	reflect.TypeOf(&big.Rat{}):       renderBig,
+	reflect.TypeOf(net.IP{}):         renderIP,
+	reflect.TypeOf(netip.Addr{}):     renderAddr,
+	reflect.TypeOf(url.URL{}):        renderURL,
+	reflect.TypeOf(&url.URL{}):       renderURL,
+}
+
+// builtinIfaceRenderers are the interface keyed builtinRenderers.
+var builtinIfaceRenderers = []ifaceRenderer{
+	{reflect.TypeOf((*reflect.Type)(nil)).Elem(), renderType},
+}
+
+// renderTime prints a time.Time as a time.Date call in Go syntax output
+// and in RFC 3339 form in humanized output.  Compilable output can only
+// give a time in a named location (eg: "America/New_York") the fixed
+// offset it has at that time, that is reported as a problem.
+func renderTime(p Printer, v reflect.Value) {
+	t := v.Interface().(time.Time)
+	if p.Humanize() {
+		fmt.Fprint(p, t.Format(time.RFC3339Nano))
+		return
+	}
+	p.UsePackage("time")
+	var loc string
+	switch t.Location() {
+	case time.UTC:
+		loc = "time.UTC"
+	case time.Local:
+		loc = "time.Local"
+	default:
+		name, offset := t.Zone()
+		loc = fmt.Sprintf("time.FixedZone(%q, %d)", name, offset)
+		if l := t.Location().String(); l != name {
+			// time.LoadLocation can't be called within an expression
+			p.Problem("location %q is written as a fixed zone, without its daylight saving rules", l)
+		}
+	}
+	fmt.Fprintf(p, "time.Date(%d, %d, %d, %d, %d, %d, %d, %s)",
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// durationUnits are the time package's duration constants, largest first.
+var durationUnits = []struct {
+	d    time.Duration
+	name string
+}{
+	{time.Hour, "time.Hour"},
+	{time.Minute, "time.Minute"},
+	{time.Second, "time.Second"},
+	{time.Millisecond, "time.Millisecond"},
+	{time.Microsecond, "time.Microsecond"},
+	{time.Nanosecond, "time.Nanosecond"},
+}
+
+// renderDuration prints a time.Duration as a multiple of the largest
+// unit dividing it (eg: "5*time.Second") in Go syntax output and the
+// usual way (eg: "5s") in humanized output.
+func renderDuration(p Printer, v reflect.Value) {
+	d := v.Interface().(time.Duration)
+	if p.Humanize() {
+		fmt.Fprint(p, d.String())
+		return
+	}
+	p.UsePackage("time")
+	if d == 0 {
+		fmt.Fprint(p, "time.Duration(0)")
+		return
+	}
+	for _, u := range durationUnits {
+		if d%u.d == 0 {
+			fmt.Fprintf(p, "%d*%s", d/u.d, u.name)
+			return
+		}
+	}
+}
+
+// renderBig prints a big.Int, big.Float or big.Rat (or pointer to one)
+// as its decimal value, compilable output parses it from a string.
+func renderBig(p Printer, v reflect.Value) {
+	var s, parse string
+	switch x := pointerTo(v).(type) {
+	case *big.Int:
+		s, parse = x.String(), "new(big.Int).SetString(%q, 10)"
+	case *big.Float:
+		s, parse = x.Text('g', -1), "new(big.Float).SetString(%q)"
+	case *big.Rat:
+		s, parse = x.RatString(), "new(big.Rat).SetString(%q)"
+	}
+	if !p.Compilable() {
+		fmt.Fprint(p, s)
+		return
+	}
+	p.UsePackage("math/big")
+	printParsed(p, v, fmt.Sprintf(parse, s))
+}
+
+// renderIP prints a net.IP as a net.ParseIP call in Go syntax output and
+// as its address in humanized output.  An empty (but non-nil) net.IP has
+// no address, it prints as net.IP{} in either form.
+func renderIP(p Printer, v reflect.Value) {
+	ip := v.Interface().(net.IP)
+	if len(ip) == 0 {
+		if !p.Humanize() {
+			p.UsePackage("net")
+		}
+		fmt.Fprint(p, "net.IP{}")
+		return
+	}
+	if p.Humanize() {
+		fmt.Fprint(p, ip.String())
+		return
+	}
+	p.UsePackage("net")
+	fmt.Fprintf(p, "net.ParseIP(%q)", ip.String())
+}
+
+// renderAddr prints a netip.Addr as a netip.MustParseAddr call in Go
+// syntax output and as its address in humanized output.
+func renderAddr(p Printer, v reflect.Value) {
+	a := v.Interface().(netip.Addr)
+	if p.Humanize() {
+		fmt.Fprint(p, a.String())
+		return
+	}
+	p.UsePackage("net/netip")
+	if !a.IsValid() {
+		fmt.Fprint(p, "netip.Addr{}")
+		return
+	}
+	fmt.Fprintf(p, "netip.MustParseAddr(%q)", a.String())
+}
+
+// renderURL prints a url.URL (or pointer to one) as its string form,
+// quoted in Go syntax output.
+func renderURL(p Printer, v reflect.Value) {
+	s := pointerTo(v).(*url.URL).String()
+	switch {
+	case p.Compilable():
+		p.UsePackage("net/url")
+		printParsed(p, v, fmt.Sprintf("url.Parse(%q)", s))
+	case p.Humanize():
+		fmt.Fprint(p, s)
+	default:
+		fmt.Fprint(p, strconv.Quote(s))
+	}
+}
+
+// renderType prints a reflect.Type as its name, compilable output gets
+// it with reflect.TypeOf.
+func renderType(p Printer, v reflect.Value) {
+	t := v.Interface().(reflect.Type)
+	if p.Compilable() {
+		p.UsePackage("reflect")
+		fmt.Fprintf(p, "reflect.TypeOf((*%s)(nil)).Elem()", p.TypeString(t))
+		return
+	}
+	fmt.Fprint(p, t.String())
+}
+
+// printParsed prints a compilable expression for v, a value of pointer
+// type T (or T's element type) given the expression parse, which yields a
+// T and an ignored second result.
+func printParsed(p Printer, v reflect.Value, parse string) {
+	t, deref := v.Type(), ""
+	if t.Kind() != reflect.Ptr {
+		t, deref = reflect.PointerTo(t), "*"
+	}
+	fmt.Fprintf(p, "%sfunc() %s { v, _ := %s; return v }()", deref, p.TypeString(t), parse)
+}
+
+// pointerTo returns v as an interface holding a pointer, so pointer
+// methods can be called on values, making a copy of v if needed.
+func pointerTo(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		return v.Interface()
+	}
+	pv := reflect.New(v.Type())
+	pv.Elem().Set(v)
+	return pv.Interface()
+}
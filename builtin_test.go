@@ -0,0 +1,91 @@
+package pretty
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Event struct {
+	At      time.Time
+	Took    time.Duration
+	Count   *big.Int
+	Ratio   *big.Rat
+	From    net.IP
+	To      netip.Addr
+	Link    *url.URL
+	Kind    reflect.Type
+	private time.Time
+}
+
+var (
+	when  = time.Date(2024, 1, 2, 15, 4, 5, 600, time.UTC)
+	count = new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)
+	link  = &url.URL{Scheme: "https", Host: "example.com", Path: "/a b"}
+)
+
+var builtintests = []struct {
+	v         interface{}
+	gosyntax  string
+	humanized string
+}{
+	{when, "time.Date(2024, 1, 2, 15, 4, 5, 600, time.UTC)", "2024-01-02T15:04:05.0000006Z"},
+	{
+		when.In(time.FixedZone("EST", -5*60*60)),
+		`time.Date(2024, 1, 2, 10, 4, 5, 600, time.FixedZone("EST", -18000))`,
+		"2024-01-02T10:04:05.0000006-05:00",
+	},
+	{5 * time.Second, "5*time.Second", "5s"},
+	{-90 * time.Minute, "-90*time.Minute", "-1h30m0s"},
+	{1500 * time.Microsecond, "1500*time.Microsecond", "1.5ms"},
+	{time.Duration(0), "time.Duration(0)", "0s"},
+	{count, "1000000000000000000000000000000", "1000000000000000000000000000000"},
+	{*big.NewInt(-7), "-7", "-7"},
+	{big.NewFloat(1.5), "1.5", "1.5"},
+	{big.NewRat(3, 4), "3/4", "3/4"},
+	{net.ParseIP("192.0.2.1"), `net.ParseIP("192.0.2.1")`, "192.0.2.1"},
+	{net.IP(nil), "net.IP(nil)", "nil"},
+	{net.IP{}, "net.IP{}", "net.IP{}"},
+	{netip.MustParseAddr("2001:db8::1"), `netip.MustParseAddr("2001:db8::1")`, "2001:db8::1"},
+	{netip.Addr{}, "netip.Addr{}", "invalid IP"},
+	{link, `"https://example.com/a%20b"`, "https://example.com/a%20b"},
+	{reflect.TypeOf(T{}), "pretty.T", "pretty.T"},
+	{
+		Event{At: when, Took: time.Second, Count: count, From: net.IPv4(10, 0, 0, 1), private: when},
+		`pretty.Event{
+    At:      time.Date(2024, 1, 2, 15, 4, 5, 600, time.UTC),
+    Took:    1*time.Second,
+    Count:   1000000000000000000000000000000,
+    Ratio:   (*big.Rat)(nil),
+    From:    net.ParseIP("10.0.0.1"),
+    To:      netip.Addr{},
+    Link:    (*url.URL)(nil),
+    Kind:    nil,
+    private: time.Date(2024, 1, 2, 15, 4, 5, 600, time.UTC),
+}`,
+		"",
+	},
+}
+
+func TestBuiltinRenderers(t *testing.T) {
+	hc := NewConfig()
+	hc.Humanize = true
+	for _, tt := range builtintests {
+		if s := fmt.Sprintf("%# v", Formatter(tt.v)); s != tt.gosyntax {
+			t.Errorf("expected %q", tt.gosyntax)
+			t.Errorf("got      %q", s)
+		}
+		if tt.humanized == "" {
+			continue
+		}
+		if s := fmt.Sprintf("%# v", hc.Formatter(tt.v)); s != tt.humanized {
+			t.Errorf("expected %q", tt.humanized)
+			t.Errorf("got      %q", s)
+		}
+	}
+}
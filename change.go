@@ -0,0 +1,206 @@
+package pretty
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A Change is one difference between two values, as found by Changes.
+type Change struct {
+	// Path leads from the values compared to where they differ, it is
+	// empty if they differ as a whole.
+	Path Path
+
+	// Kind says how they differ.
+	Kind ChangeKind
+
+	// Old and New are the differing values from the first and second of
+	// the values compared, one is the zero Value for an Added or Removed
+	// map entry or element and for a nil interface.
+	Old, New reflect.Value
+
+	// OldRef and NewRef are set for a CycleChanged, each is the path to
+	// the value that Old or New refers back to, or nil if it doesn't.
+	OldRef, NewRef *Path
+
+	// c is the Config of the Diff that found the Change.
+	c *Config
+}
+
+// ChangeKind says how two values differ.
+type ChangeKind int
+
+const (
+	// Modified values have the same type but different contents.
+	Modified ChangeKind = iota
+
+	// Added and Removed map entries and array or slice elements are
+	// only in the New or the Old value.
+	Added
+	Removed
+
+	// TypeChanged values (held by interfaces) have different types.
+	TypeChanged
+
+	// LengthChanged slices have different lengths, with too many
+	// elements differing to say which (see Config.MaxDiffAlign).
+	LengthChanged
+
+	// CycleChanged values are pointers, maps or slices that are part of
+	// differently shaped cycles, see OldRef and NewRef.
+	CycleChanged
+)
+
+var changeKindNames = []string{
+	Modified:      "modified",
+	Added:         "added",
+	Removed:       "removed",
+	TypeChanged:   "type changed",
+	LengthChanged: "length changed",
+	CycleChanged:  "cycle changed",
+}
+
+func (k ChangeKind) String() string {
+	if k < 0 || int(k) >= len(changeKindNames) {
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+	return changeKindNames[k]
+}
+
+// A Path is the series of steps from a value down to one of the values
+// within it.  In a Change the index of an Added element is its index in
+// the New value, all other indexes are in the Old one.
+type Path []PathStep
+
+// PathStep is one step of a Path, down to a struct field, a map entry or
+// an array or slice element.  An element of a slice matched by a key
+// field (see Config.KeyFields) has its Index, its key as Key and the
+// name of the key field as KeyName.
+type PathStep struct {
+	Field   string        // a struct field name, or
+	Key     reflect.Value // a map key, or
+	Index   int           // an array or slice index
+	KeyName string
+}
+
+// String returns p the way Diff labels differences (eg: `S.C[2]`,
+// `Attrs["k"]` or `Users[id=42]`).
+func (p Path) String() string {
+	var b strings.Builder
+	for i, s := range p {
+		if s.Field != "" && i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(s.segment())
+	}
+	return b.String()
+}
+
+// segment returns s the way it is written in a Path.
+func (s PathStep) segment() string {
+	switch {
+	case s.Field != "":
+		return s.Field
+	case s.KeyName != "":
+		return fmt.Sprintf("[%s=%#v]", s.KeyName, s.Key)
+	case s.Key.IsValid():
+		return fmt.Sprintf("[%#v]", s.Key)
+	}
+	return fmt.Sprintf("[%d]", s.Index)
+}
+
+// String returns ch the way Diff describes it, with the options of the
+// Config whose Changes found it (the default one for a Change made some
+// other way).
+func (ch Change) String() string {
+	if ch.c == nil {
+		return defaultConfig.changeString(ch)
+	}
+	return ch.c.changeString(ch)
+}
+
+// changeString returns ch the way Diff describes it, formatting values
+// with the options in c.
+func (c *Config) changeString(ch Change) string {
+	var s string
+	switch ch.Kind {
+	case TypeChanged:
+		s = fmt.Sprintf("%v != %v (%s != %s)", ch.Old.Type(), ch.New.Type(), c.nilString(ch.Old), c.nilString(ch.New))
+	case LengthChanged:
+		s = fmt.Sprintf("%s[%d] != %s[%d]", ch.Old.Type(), ch.Old.Len(), ch.New.Type(), ch.New.Len())
+	case Removed:
+		s = c.valueString(ch.Old) + " != (missing)"
+	case Added:
+		s = "(missing) != " + c.valueString(ch.New)
+	case CycleChanged:
+		s = cycleString(ch.OldRef) + " != " + cycleString(ch.NewRef)
+	default:
+		if ch.Old.Kind() == reflect.String && ch.New.Kind() == reflect.String && c.diffStrings(ch.Old.String(), ch.New.String()) {
+			s = c.stringDiff(ch.Old.String(), ch.New.String())
+		} else if isNil(ch.Old) || isNil(ch.New) {
+			s = c.nilString(ch.Old) + " != " + c.nilString(ch.New)
+		} else {
+			s = c.valueString(ch.Old) + " != " + c.valueString(ch.New)
+		}
+	}
+	if len(ch.Path) > 0 {
+		s = ch.Path.String() + ": " + s
+	}
+	return s
+}
+
+// valueString returns v the way Diff shows an element on its own, scalar
+// values plainly and others as Formatter shows them.
+func (c *Config) valueString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return c.nilString(v)
+	}
+	return scalarString(v)
+}
+
+// isNil reports whether v is missing or a nil pointer.
+func isNil(v reflect.Value) bool {
+	return !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// nilString returns v, when it or the value it is compared with is nil,
+// as "nil" or as Formatter shows it.
+func (c *Config) nilString(v reflect.Value) string {
+	if isNil(v) {
+		return "nil"
+	}
+	return fmt.Sprintf("%# v", formatter{c: c, v: v, quote: true})
+}
+
+// scalarString returns v, a value compared as a whole, the way Diff
+// shows it.
+func scalarString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%d", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%v", v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprintf("%v", v.Complex())
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Sprintf("%#x", v.Pointer())
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case reflect.Bool:
+		return fmt.Sprintf("%v", v.Bool())
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// cycleString returns how a reference back to the value at the end of p
+// is shown, the same way Formatter shows cycles.
+func cycleString(p *Path) string {
+	if p == nil {
+		return "(no cyclic reference)"
+	}
+	return "(CYCLIC REFERENCE to ." + p.String() + ")"
+}
@@ -0,0 +1,158 @@
+package pretty
+
+import (
+	"reflect"
+)
+
+// Config holds the options that control how values are pretty-printed
+// and diffed.  Each Config is independent of the others, so different
+// subsystems (or goroutines) can hold their own printers without
+// stepping on each other's output style.  The package-level functions
+// (Formatter, Sprint, Diff, SetHumanize and friends) all use a shared
+// default Config.
+//
+// A Config should not be modified while it is in use by another
+// goroutine.  Use NewConfig to get a Config with the package defaults.
+type Config struct {
+	// OutputIndentLevel is the step-wise indent, in spaces, used for
+	// nested structure representations (see SetOutputIndentLevel).
+	OutputIndentLevel int
+
+	// Humanize selects the human friendly output form instead of the
+	// Go-like structure output (see SetHumanize).
+	Humanize bool
+
+	// OutputPrefixStr is prefixed to every line of output produced by
+	// the printing wrappers (see SetOutputPrefixStr).
+	OutputPrefixStr string
+
+	// NewlineAfterItems inserts an empty line between items in humanized
+	// output (see SetNewlineAfterItems).
+	NewlineAfterItems bool
+
+	// SortMapKeys prints map entries, and Diff reports their changes, in
+	// sorted key order so the output for a given map is always the same.
+	// Turn it off to use Go's (random) iteration order, which is a
+	// little faster.
+	SortMapKeys bool
+
+	// MaxDepth limits how many levels of nested maps, structs, arrays and
+	// slices are printed, deeper ones are summarized by their type and
+	// size (eg: "pkg.Type{…3 fields}").  Zero or less means no limit.
+	MaxDepth int
+
+	// MaxStringLen, MaxElements and MaxMapEntries truncate long strings
+	// (in bytes), arrays and slices, and maps respectively, the rest is
+	// replaced by a marker such as "... (4980 more elements)".  Zero or
+	// less means no limit.
+	MaxStringLen  int
+	MaxElements   int
+	MaxMapEntries int
+
+	// CallMethods prints values, at any depth, using their own methods
+	// instead of their internal structure: GoString in Go syntax output
+	// and Error or String in humanized output.  Unlike package fmt, which
+	// never calls methods on unexported struct fields, this calls them
+	// on those too, reading the fields through package unsafe.
+	CallMethods bool
+
+	// LabelShared makes Go syntax output show which pointers, maps and
+	// slices are referred to more than once: the first is printed with a
+	// label (eg: "#1=&pkg.T{...}") and the others as just the label
+	// ("#1").  It has no effect on humanized or compilable output.
+	LabelShared bool
+
+	// Compilable makes Go syntax output a compilable Go expression (see
+	// Literal), it has no effect on humanized output.
+	Compilable bool
+
+	// MaxDiffAlign limits the work Diff does aligning the elements of
+	// arrays and slices, so that an element inserted or deleted is
+	// reported as such rather than every element after it differing.
+	// Aligning costs the number of elements differing in one times that
+	// in the other, all the alignments of one Diff share the limit and
+	// once it is used up elements are compared index by index instead.
+	// Zero or less means no limit.
+	MaxDiffAlign int
+
+	// DiffStrings makes Diff describe strings that differ by where they
+	// differ rather than by quoting both in full: multi-line strings get
+	// a unified diff of their lines with DiffContext unchanged lines
+	// around each change, single-line ones are cut down to the part
+	// around the offset (in runes) of their first difference.  Strings
+	// with no newline and shorter than DiffStringsMinLen bytes are still
+	// quoted in full.
+	DiffStrings       bool
+	DiffContext       int
+	DiffStringsMinLen int
+
+	// IgnoreFields, IgnorePaths and IgnoreUnexported make Diff skip
+	// parts of the values compared: struct fields with any of the given
+	// names, struct fields and map entries at any of the given paths
+	// (eg: "Items[*].UpdatedAt" or `Attrs["ts"]`, where "[*]" stands for
+	// any index or key and "*" for any field name) and unexported struct
+	// fields.  Diff always skips fields tagged `pretty:"-"`, which
+	// humanized output leaves out too, and those tagged with the
+	// "nodiff" option (eg: `pretty:"name,nodiff"`), which it shows.
+	IgnoreFields     []string
+	IgnorePaths      []string
+	IgnoreUnexported bool
+
+	// FloatAbsTol, FloatRelTol and FloatULPs make Diff treat floating
+	// point numbers (and the real and imaginary parts of complex ones)
+	// as equal when they are within the given absolute difference, the
+	// given difference relative to the larger magnitude, or the given
+	// number of representable values of each other.  Zero means no
+	// such tolerance.  NaNEqual makes NaN equal to NaN.
+	FloatAbsTol float64
+	FloatRelTol float64
+	FloatULPs   uint64
+	NaNEqual    bool
+
+	// UnorderedSlices and UnorderedTypes make Diff compare arrays and
+	// slices, all of them or those of the given types, as multisets:
+	// only elements in one and not the other are reported, as Removed
+	// or Added, regardless of order.  A struct field can be compared that
+	// way by tagging it with the "unordered" option (eg:
+	// `pretty:",unordered"`).
+	UnorderedSlices bool
+	UnorderedTypes  []reflect.Type
+
+	// KeyFields makes Diff match up the elements of slices of the given
+	// struct types (or pointers to them) by the value of the named field
+	// rather than by position, eg: a User with ID 42 is compared with the
+	// User with ID 42 of the other slice, wherever it is, and its changes
+	// reported as `Users[ID=42].Email`.  A key field can also be chosen
+	// by tagging it with the "key" option, its tag name then labels it
+	// (eg: `pretty:"id,key"` gives `Users[id=42]`).
+	KeyFields map[reflect.Type]string
+
+	// UseEqual makes Diff compare values whose type has an Equal method
+	// taking a value of the same type and returning a bool (such as
+	// time.Time) by calling it, instead of comparing their contents.
+	// Like CallMethods it calls Equal on unexported struct fields too.
+	UseEqual bool
+
+	// renderers and ifaceRenderers hold the Renderers added by Register.
+	renderers      map[reflect.Type]Renderer
+	ifaceRenderers []ifaceRenderer
+
+	// comparers holds the Comparers added by RegisterComparer.
+	comparers map[reflect.Type]Comparer
+}
+
+// NewConfig returns a new Config initialized with the package defaults.
+func NewConfig() *Config {
+	return &Config{
+		OutputIndentLevel: 4,
+		SortMapKeys:       true,
+		MaxDepth:          10,
+		MaxDiffAlign:      1 << 16,
+		DiffContext:       3,
+		UseEqual:          true,
+	}
+}
+
+// defaultConfig is used by all the package-level functions, the various
+// Set*() routines adjust it.
+var defaultConfig = NewConfig()
@@ -16,7 +16,12 @@ func (p *sbuf) Printf(format string, a ...interface{}) {
 // Diff returns a slice where each element describes
 // a difference between a and b.
 func Diff(a, b interface{}) (desc []string) {
-	Pdiff((*sbuf)(&desc), a, b)
+	return defaultConfig.Diff(a, b)
+}
+
+// Diff is like the package-level Diff but uses the options in c.
+func (c *Config) Diff(a, b interface{}) (desc []string) {
+	c.Pdiff((*sbuf)(&desc), a, b)
 	return desc
 }
 
@@ -30,7 +35,12 @@ func (p *wprintfer) Printf(format string, a ...interface{}) {
 
 // Fdiff writes to w a description of the differences between a and b.
 func Fdiff(w io.Writer, a, b interface{}) {
-	Pdiff(&wprintfer{w}, a, b)
+	defaultConfig.Fdiff(w, a, b)
+}
+
+// Fdiff is like the package-level Fdiff but uses the options in c.
+func (c *Config) Fdiff(w io.Writer, a, b interface{}) {
+	c.Pdiff(&wprintfer{w}, a, b)
 }
 
 type Printfer interface {
@@ -41,7 +51,38 @@ type Printfer interface {
 // It calls Printf once for each difference, with no trailing newline.
 // The standard library log.Logger is a Printfer.
 func Pdiff(p Printfer, a, b interface{}) {
-	diffPrinter{w: p}.diff(reflect.ValueOf(a), reflect.ValueOf(b))
+	defaultConfig.Pdiff(p, a, b)
+}
+
+// Pdiff is like the package-level Pdiff but uses the options in c.
+func (c *Config) Pdiff(p Printfer, a, b interface{}) {
+	c.changes(a, b, func(ch Change) {
+		p.Printf("%s", c.changeString(ch))
+	})
+}
+
+// Changes returns the differences between a and b, the same ones Diff
+// describes, in a form that can be inspected.
+func Changes(a, b interface{}) []Change {
+	return defaultConfig.Changes(a, b)
+}
+
+// Changes is like the package-level Changes but uses the options in c.
+func (c *Config) Changes(a, b interface{}) (changes []Change) {
+	c.changes(a, b, func(ch Change) {
+		changes = append(changes, ch)
+	})
+	return changes
+}
+
+// changes calls report for each difference between a and b.
+func (c *Config) changes(a, b interface{}, report func(Change)) {
+	av, bv := addressable(reflect.ValueOf(a)), addressable(reflect.ValueOf(b))
+	w := differ{c: c, report: report, ignore: c.ignorePatterns()}
+	if max := c.MaxDiffAlign; max > 0 {
+		w.budget = &max
+	}
+	w.diff(av, bv)
 }
 
 type Logfer interface {
@@ -60,127 +101,220 @@ func (p *logprintfer) Printf(format string, a ...interface{}) {
 // It calls Logf once for each difference, with no trailing newline.
 // The standard library testing.T and testing.B are Logfers.
 func Ldiff(l Logfer, a, b interface{}) {
-	Pdiff(&logprintfer{l}, a, b)
+	defaultConfig.Ldiff(l, a, b)
 }
 
-type diffPrinter struct {
-	w Printfer
-	l string // label
+// Ldiff is like the package-level Ldiff but uses the options in c.
+func (c *Config) Ldiff(l Logfer, a, b interface{}) {
+	c.Pdiff(&logprintfer{l}, a, b)
 }
 
-func (w diffPrinter) printf(f string, a ...interface{}) {
-	var l string
-	if w.l != "" {
-		l = w.l + ": "
+// differ walks two values side by side, reporting each Change found.
+type differ struct {
+	c      *Config
+	report func(Change)
+	path   Path
+
+	// ignore holds the parsed Config.IgnorePaths.
+	ignore []pathPattern
+
+	// unordered is set for comparing a struct field tagged "unordered",
+	// through any pointers and interfaces, and cleared for what is within.
+	unordered bool
+
+	// ancestors are the pairs of pointers, maps and slices being compared
+	// that the current values are within, they keep diff out of cycles.
+	ancestors *diffAncestor
+
+	// budget is what is left of Config.MaxDiffAlign for the whole Diff,
+	// nil if there is no limit.
+	budget *int
+
+	// stop is set while only checking whether values are equal (see
+	// equal), once the first difference has been found.
+	stop *bool
+}
+
+// refPair is a pair of pointers, maps or slices being compared.
+type refPair struct {
+	a, b ref
+}
+
+// diffAncestor is an ancestor (see ancestor) of both of the values
+// being compared.
+type diffAncestor struct {
+	up *diffAncestor
+	refPair
+	path Path
+}
+
+// cycles returns the ancestors, if any, that r.a and r.b refer back to.
+func (d *diffAncestor) cycles(r refPair) (ca, cb *diffAncestor) {
+	for ; d != nil; d = d.up {
+		if ca == nil && d.a == r.a {
+			ca = d
+		}
+		if cb == nil && d.b == r.b {
+			cb = d
+		}
 	}
-	w.w.Printf(l+f, a...)
+	return ca, cb
 }
 
-func (w diffPrinter) diff(av, bv reflect.Value) {
-	if !av.IsValid() && bv.IsValid() {
-		w.printf("nil != %# v", formatter{v: bv, quote: true})
-		return
+// enter returns a copy of w for comparing what av and bv, pointers, maps
+// or slices of the same type, refer to.  It returns false if there is no
+// need to, as both refer back to the same pair of ancestors.  When only
+// one of them does, or they refer back to different ones, the two have
+// differently shaped cycles and that is reported as their difference.
+func (w differ) enter(av, bv reflect.Value) (differ, bool) {
+	ar, aok := refOf(av)
+	br, bok := refOf(bv)
+	if !aok || !bok {
+		return w, true
+	}
+	r := refPair{ar, br}
+	ca, cb := w.ancestors.cycles(r)
+	if ca != nil && ca == cb {
+		return w, false
+	}
+	if ca != nil || cb != nil {
+		ch := Change{Path: w.path, Kind: CycleChanged, Old: av, New: bv, c: w.c}
+		if ca != nil {
+			ch.OldRef = &ca.path
+		}
+		if cb != nil {
+			ch.NewRef = &cb.path
+		}
+		w.report(ch)
+		return w, false
 	}
-	if av.IsValid() && !bv.IsValid() {
-		w.printf("%# v != nil", formatter{v: av, quote: true})
+	w.ancestors = &diffAncestor{up: w.ancestors, refPair: r, path: w.path}
+	return w, true
+}
+
+// change reports a Change of the given kind at the current path.
+func (w differ) change(kind ChangeKind, av, bv reflect.Value) {
+	w.report(Change{Path: w.path, Kind: kind, Old: av, New: bv, c: w.c})
+}
+
+func (w differ) diff(av, bv reflect.Value) {
+	if w.stop != nil && *w.stop {
 		return
 	}
 	if !av.IsValid() && !bv.IsValid() {
 		return
 	}
+	if !av.IsValid() || !bv.IsValid() {
+		w.change(Modified, av, bv)
+		return
+	}
 
 	at := av.Type()
 	bt := bv.Type()
 	if at != bt {
-		w.printf("%v != %v", at, bt)
+		w.change(TypeChanged, av, bv)
+		return
+	}
+	if eq, ok := w.c.compare(av, bv); ok {
+		if !eq {
+			w.change(Modified, av, bv)
+		}
+		return
+	}
+	w, ok := w.enter(av, bv)
+	if !ok {
 		return
 	}
 
 	switch kind := at.Kind(); kind {
 	case reflect.Bool:
 		if a, b := av.Bool(), bv.Bool(); a != b {
-			w.printf("%v != %v", a, b)
+			w.change(Modified, av, bv)
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if a, b := av.Int(), bv.Int(); a != b {
-			w.printf("%d != %d", a, b)
+			w.change(Modified, av, bv)
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		if a, b := av.Uint(), bv.Uint(); a != b {
-			w.printf("%d != %d", a, b)
+			w.change(Modified, av, bv)
 		}
 	case reflect.Float32, reflect.Float64:
-		if a, b := av.Float(), bv.Float(); a != b {
-			w.printf("%v != %v", a, b)
+		if a, b := av.Float(), bv.Float(); !w.c.floatEqual(a, b, at.Bits()) {
+			w.change(Modified, av, bv)
 		}
 	case reflect.Complex64, reflect.Complex128:
-		if a, b := av.Complex(), bv.Complex(); a != b {
-			w.printf("%v != %v", a, b)
+		if a, b := av.Complex(), bv.Complex(); !w.c.complexEqual(a, b, at.Bits()) {
+			w.change(Modified, av, bv)
 		}
-	case reflect.Array:
-		n := av.Len()
-		for i := 0; i < n; i++ {
-			w.relabel(fmt.Sprintf("[%d]", i)).diff(av.Index(i), bv.Index(i))
+	case reflect.Array, reflect.Slice:
+		if kf, ok := w.c.keyField(at.Elem()); ok {
+			w.diffKeyed(av, bv, kf)
+		} else if w.unordered || w.c.unorderedType(at) {
+			w.diffUnordered(av, bv)
+		} else {
+			w.diffElems(av, bv)
 		}
 	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
 		if a, b := av.Pointer(), bv.Pointer(); a != b {
-			w.printf("%#x != %#x", a, b)
+			w.change(Modified, av, bv)
 		}
 	case reflect.Interface:
 		w.diff(av.Elem(), bv.Elem())
 	case reflect.Map:
-		ak, both, bk := keyDiff(av.MapKeys(), bv.MapKeys())
-		for _, k := range ak {
-			w := w.relabel(fmt.Sprintf("[%#v]", k))
-			w.printf("%q != (missing)", av.MapIndex(k))
+		// keys from unexported fields can only be hashed if readable
+		av, bv = readable(av), readable(bv)
+		akeys, avals := mapEntries(av, w.c.SortMapKeys)
+		bkeys, bvals := mapEntries(bv, w.c.SortMapKeys)
+		ak, both, bk := keyDiff(akeys, bkeys)
+		for _, i := range ak {
+			if w := w.at(PathStep{Key: akeys[i]}); !w.ignorePath() {
+				w.change(Removed, avals[i], reflect.Value{})
+			}
 		}
-		for _, k := range both {
-			w := w.relabel(fmt.Sprintf("[%#v]", k))
-			w.diff(av.MapIndex(k), bv.MapIndex(k))
+		for _, ij := range both {
+			if w := w.at(PathStep{Key: akeys[ij[0]]}); !w.ignorePath() {
+				w.diff(avals[ij[0]], bvals[ij[1]])
+			}
 		}
-		for _, k := range bk {
-			w := w.relabel(fmt.Sprintf("[%#v]", k))
-			w.printf("(missing) != %q", bv.MapIndex(k))
+		for _, j := range bk {
+			if w := w.at(PathStep{Key: bkeys[j]}); !w.ignorePath() {
+				w.change(Added, reflect.Value{}, bvals[j])
+			}
 		}
 	case reflect.Ptr:
 		switch {
-		case av.IsNil() && !bv.IsNil():
-			w.printf("nil != %# v", formatter{v: bv, quote: true})
-		case !av.IsNil() && bv.IsNil():
-			w.printf("%# v != nil", formatter{v: av, quote: true})
-		case !av.IsNil() && !bv.IsNil():
+		case av.IsNil() != bv.IsNil():
+			w.change(Modified, av, bv)
+		case !av.IsNil():
 			w.diff(av.Elem(), bv.Elem())
 		}
-	case reflect.Slice:
-		lenA := av.Len()
-		lenB := bv.Len()
-		if lenA != lenB {
-			w.printf("%s[%d] != %s[%d]", av.Type(), lenA, bv.Type(), lenB)
-			break
-		}
-		for i := 0; i < lenA; i++ {
-			w.relabel(fmt.Sprintf("[%d]", i)).diff(av.Index(i), bv.Index(i))
-		}
 	case reflect.String:
 		if a, b := av.String(), bv.String(); a != b {
-			w.printf("%q != %q", a, b)
+			w.change(Modified, av, bv)
 		}
 	case reflect.Struct:
 		for i := 0; i < av.NumField(); i++ {
-			w.relabel(at.Field(i).Name).diff(av.Field(i), bv.Field(i))
+			f := at.Field(i)
+			if w.c.ignoreField(f) {
+				continue
+			}
+			if w := w.at(PathStep{Field: f.Name}); !w.ignorePath() {
+				_, opts := parseTag(f.Tag.Get("pretty"))
+				w.unordered = opts.Contains("unordered")
+				w.diff(av.Field(i), bv.Field(i))
+			}
 		}
 	default:
 		panic("unknown reflect Kind: " + kind.String())
 	}
 }
 
-func (d diffPrinter) relabel(name string) (d1 diffPrinter) {
-	d1 = d
-	if d.l != "" && name[0] != '[' {
-		d1.l += "."
-	}
-	d1.l += name
-	return d1
+// at returns a copy of w for comparing the elements reached by s.
+func (w differ) at(s PathStep) differ {
+	w.path = append(w.path[:len(w.path):len(w.path)], s)
+	w.unordered = false
+	return w
 }
 
 // keyEqual compares a and b for equality.
@@ -235,21 +369,67 @@ func keyEqual(av, bv reflect.Value) bool {
 	}
 }
 
-func keyDiff(a, b []reflect.Value) (ak, both, bk []reflect.Value) {
+// keyDiff splits the keys of two maps into those only in the first (ak),
+// those in both (both, pairs of indexes in the first and the second) and
+// those only in the second (bk), each in the order given.  It looks keys
+// up in a Go map, falling back on comparing every pair with keyEqual when
+// a key can't be had as an interface{}, as for a map in an unexported
+// struct field that isn't addressable (see readable).  Either way a NaN
+// key matches no other.
+func keyDiff(a, b []reflect.Value) (ak []int, both [][2]int, bk []int) {
+	if !canInterface(a) || !canInterface(b) {
+		return keyDiffPairs(a, b)
+	}
+	inA := make(map[interface{}]bool, len(a))
 	for _, av := range a {
+		inA[av.Interface()] = true
+	}
+	inB := make(map[interface{}]int, len(b))
+	for j, bv := range b {
+		inB[bv.Interface()] = j
+	}
+	for i, av := range a {
+		if j, ok := inB[av.Interface()]; ok {
+			both = append(both, [2]int{i, j})
+		} else {
+			ak = append(ak, i)
+		}
+	}
+	for j, bv := range b {
+		if !inA[bv.Interface()] {
+			bk = append(bk, j)
+		}
+	}
+	return
+}
+
+// canInterface reports whether all of vs can be had as interface{}s.
+func canInterface(vs []reflect.Value) bool {
+	for _, v := range vs {
+		if !v.CanInterface() {
+			return false
+		}
+	}
+	return true
+}
+
+// keyDiffPairs is keyDiff comparing every pair of keys, taking time in
+// proportion to len(a)*len(b).
+func keyDiffPairs(a, b []reflect.Value) (ak []int, both [][2]int, bk []int) {
+	for i, av := range a {
 		inBoth := false
-		for _, bv := range b {
+		for j, bv := range b {
 			if keyEqual(av, bv) {
 				inBoth = true
-				both = append(both, av)
+				both = append(both, [2]int{i, j})
 				break
 			}
 		}
 		if !inBoth {
-			ak = append(ak, av)
+			ak = append(ak, i)
 		}
 	}
-	for _, bv := range b {
+	for j, bv := range b {
 		inBoth := false
 		for _, av := range a {
 			if keyEqual(av, bv) {
@@ -258,7 +438,7 @@ func keyDiff(a, b []reflect.Value) (ak, both, bk []reflect.Value) {
 			}
 		}
 		if !inBoth {
-			bk = append(bk, bv)
+			bk = append(bk, j)
 		}
 	}
 	return
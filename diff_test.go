@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -46,17 +49,17 @@ var diffs = []difftest{
 	{a: nil, b: nil},
 	{a: S{A: 1}, b: S{A: 1}},
 
-	{0, "", []string{`int != string`}},
+	{0, "", []string{`int != string (int(0) != "")`}},
 	{0, 1, []string{`0 != 1`}},
-	{S{}, new(S), []string{`pretty.S != *pretty.S`}},
+	{S{}, new(S), []string{`pretty.S != *pretty.S (pretty.S{} != &pretty.S{})`}},
 	{"a", "b", []string{`"a" != "b"`}},
 	{S{}, S{A: 1}, []string{`A: 0 != 1`}},
 	{new(S), &S{A: 1}, []string{`A: 0 != 1`}},
 	{S{S: new(S)}, S{S: &S{A: 1}}, []string{`S.A: 0 != 1`}},
 	{S{}, S{I: 0}, []string{`I: nil != int(0)`}},
-	{S{I: 1}, S{I: "x"}, []string{`I: int != string`}},
-	{S{}, S{C: []int{1}}, []string{`C: []int[0] != []int[1]`}},
-	{S{C: []int{}}, S{C: []int{1}}, []string{`C: []int[0] != []int[1]`}},
+	{S{I: 1}, S{I: "x"}, []string{`I: int != string (int(1) != "x")`}},
+	{S{}, S{C: []int{1}}, []string{`C[0]: (missing) != 1`}},
+	{S{C: []int{}}, S{C: []int{1}}, []string{`C[0]: (missing) != 1`}},
 	{S{C: []int{1, 2, 3}}, S{C: []int{1, 2, 4}}, []string{`C[2]: 3 != 4`}},
 	{S{}, S{A: 1, S: new(S)}, []string{`A: 0 != 1`, `S: nil != &pretty.S{}`}},
 
@@ -101,11 +104,13 @@ var diffs = []difftest{
 	{struct{ x func() }{f0}, struct{ x func() }{f1}, []string{fmt.Sprintf("x: %p != %p", f0, f1)}},
 	{struct{ x interface{} }{0}, struct{ x interface{} }{0}, nil},
 	{struct{ x interface{} }{0}, struct{ x interface{} }{1}, []string{`x: 0 != 1`}},
-	{struct{ x interface{} }{0}, struct{ x interface{} }{""}, []string{`x: int != string`}},
+	{struct{ x interface{} }{0}, struct{ x interface{} }{""}, []string{`x: int != string (int(0) != "")`}},
 	{struct{ x interface{} }{0}, struct{ x interface{} }{nil}, []string{`x: int(0) != nil`}},
 	{struct{ x interface{} }{nil}, struct{ x interface{} }{0}, []string{`x: nil != int(0)`}},
 	{struct{ x map[int]int }{map[int]int{0: 0}}, struct{ x map[int]int }{map[int]int{0: 0}}, nil},
 	{struct{ x map[int]int }{map[int]int{0: 0}}, struct{ x map[int]int }{map[int]int{0: 1}}, []string{`x[0]: 0 != 1`}},
+	{map[int][]int{1: {1}}, map[int][]int{2: nil}, []string{`[1]: []int{1} != (missing)`, `[2]: (missing) != []int(nil)`}},
+	{map[int]interface{}{0: 1.5}, map[int]interface{}{0: []int{1}}, []string{`[0]: float64 != []int (float64(1.5) != []int{1})`}},
 	{struct{ x *int }{new(int)}, struct{ x *int }{new(int)}, nil},
 	{struct{ x *int }{&i0}, struct{ x *int }{&i1}, []string{`x: 0 != 1`}},
 	{struct{ x *int }{nil}, struct{ x *int }{&i0}, []string{`x: nil != &int(0)`}},
@@ -211,3 +216,606 @@ func minus(t *testing.T, s string, a, b []string) {
 		}
 	}
 }
+
+func TestDiffCycle(t *testing.T) {
+	ring := func(as ...int) *S {
+		var first, last *S
+		for _, a := range as {
+			s := &S{A: a}
+			if first == nil {
+				first = s
+			} else {
+				last.S = s
+			}
+			last = s
+		}
+		last.S = first
+		return first
+	}
+	self := func(k string) map[string]interface{} {
+		m := map[string]interface{}{"k": k}
+		m["self"] = m
+		return m
+	}
+	var x, y interface{}
+	x, y = &x, &y
+	for _, tt := range []difftest{
+		{x, y, nil},
+		{ring(1, 2), ring(1, 2), nil},
+		{ring(1, 2), ring(1, 3), []string{`S.A: 2 != 3`}},
+		{ring(1, 1), ring(1), []string{`S: (no cyclic reference) != (CYCLIC REFERENCE to .)`}},
+		{ring(1), ring(1, 1), []string{`S: (CYCLIC REFERENCE to .) != (no cyclic reference)`}},
+		{ring(1, 2, 3), ring(1, 2, 3), nil},
+		{self("a"), self("a"), nil},
+		{self("a"), self("b"), []string{`["k"]: "a" != "b"`}},
+	} {
+		diffdiff(t, Diff(tt.a, tt.b), tt.exp)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	type M struct {
+		S    S
+		Tags map[string]int
+		I    interface{}
+	}
+	a := M{S: S{A: 1, C: []int{1, 2}}, Tags: map[string]int{"x": 1, "y": 2}, I: 0}
+	b := M{S: S{A: 2, C: []int{1}}, Tags: map[string]int{"y": 2, "z": 3}, I: ""}
+	got := Changes(a, b)
+	want := []struct {
+		path string
+		kind ChangeKind
+		old  interface{}
+		new  interface{}
+		s    string
+	}{
+		{"S.A", Modified, 1, 2, `S.A: 1 != 2`},
+		{"S.C[1]", Removed, 2, nil, `S.C[1]: 2 != (missing)`},
+		{`Tags["x"]`, Removed, 1, nil, `Tags["x"]: 1 != (missing)`},
+		{`Tags["z"]`, Added, nil, 3, `Tags["z"]: (missing) != 3`},
+		{"I", TypeChanged, 0, "", `I: int != string (int(0) != "")`},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Changes() = %v, want %d changes", got, len(want))
+	}
+	value := func(v reflect.Value) interface{} {
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+	}
+	for i, w := range want {
+		ch := got[i]
+		if s := ch.Path.String(); s != w.path {
+			t.Errorf("change %d Path = %q want %q", i, s, w.path)
+		}
+		if ch.Kind != w.kind {
+			t.Errorf("change %d Kind = %v want %v", i, ch.Kind, w.kind)
+		}
+		if !reflect.DeepEqual(value(ch.Old), w.old) || !reflect.DeepEqual(value(ch.New), w.new) {
+			t.Errorf("change %d = %v, %v want %v, %v", i, value(ch.Old), value(ch.New), w.old, w.new)
+		}
+		if s := ch.String(); s != w.s {
+			t.Errorf("change %d String() = %q want %q", i, s, w.s)
+		}
+	}
+	if p := got[2].Path; len(p) != 2 || p[0].Field != "Tags" || p[1].Key.String() != "x" {
+		t.Errorf("Path = %#v, want field Tags then key x", p)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	c := NewConfig()
+	c.DiffStrings = true
+	ch := c.Changes("hello world", "hello there")
+	if len(ch) != 1 {
+		t.Fatalf("Changes = %v", ch)
+	}
+	if s, want := ch[0].String(), c.Diff("hello world", "hello there")[0]; s != want {
+		t.Errorf("String() = %q want %q", s, want)
+	}
+	if s, want := (Change{Old: reflect.ValueOf(1), New: reflect.ValueOf(2)}).String(), "1 != 2"; s != want {
+		t.Errorf("String() = %q want %q", s, want)
+	}
+}
+
+func TestDiffAlign(t *testing.T) {
+	for _, tt := range []difftest{
+		{[]int{1, 2, 3}, []int{1, 9, 2, 3}, []string{`[1]: (missing) != 9`}},
+		{[]int{1, 2, 3}, []int{1, 3}, []string{`[1]: 2 != (missing)`}},
+		{[]int{1, 2, 3}, []int{2, 3, 4}, []string{`[0]: 1 != (missing)`, `[2]: (missing) != 4`}},
+		{[]int{1, 2, 3, 4}, []int{1, 5, 3, 4, 6}, []string{`[1]: 2 != 5`, `[4]: (missing) != 6`}},
+		{[3]int{1, 2, 3}, [3]int{1, 5, 3}, []string{`[1]: 2 != 5`}},
+		{[]N{{1}, {2}, {3}}, []N{{1}, {3}}, []string{`[1]: pretty.N{N:2} != (missing)`}},
+		{[]N{{1}, {2}, {3}}, []N{{1}, {4}}, []string{`[1].N: 2 != 4`, `[2]: pretty.N{N:3} != (missing)`}},
+	} {
+		diffdiff(t, Diff(tt.a, tt.b), tt.exp)
+	}
+
+	c := NewConfig()
+	c.MaxDiffAlign = 1
+	for _, tt := range []difftest{
+		{[]int{1, 2, 3}, []int{1, 9, 8, 3}, []string{`[]int[3] != []int[4]`}},
+		{[]int{1, 2, 3, 4}, []int{1, 9, 8, 4}, []string{`[1]: 2 != 9`, `[2]: 3 != 8`}},
+		{[]int{1, 2, 3}, []int{1, 9, 2, 3}, []string{`[1]: (missing) != 9`}},
+	} {
+		diffdiff(t, c.Diff(tt.a, tt.b), tt.exp)
+	}
+
+	// the limit is shared by all the alignments of a Diff
+	type AB struct{ A, B []int }
+	c.MaxDiffAlign = 20
+	diffdiff(t, c.Diff(AB{[]int{1, 2, 3, 9}, []int{1, 2, 3, 9}}, AB{[]int{0, 1, 2, 3}, []int{0, 1, 2, 3}}), []string{
+		`A[0]: (missing) != 0`,
+		`A[3]: 9 != (missing)`,
+		`B[0]: 1 != 0`,
+		`B[1]: 2 != 1`,
+		`B[2]: 3 != 2`,
+		`B[3]: 9 != 3`,
+	})
+}
+
+// TestDiffAlignNested checks that aligning slices of values holding
+// slices doesn't align those too for every pair of elements compared.
+func TestDiffAlignNested(t *testing.T) {
+	type R struct{ V []int }
+	const n = 300
+	var a, b []R
+	for i := 0; i < n; i++ {
+		r := R{make([]int, n)}
+		for j := range r.V {
+			r.V[j] = i*n + j
+		}
+		a, b = append(a, r), append(b, r)
+	}
+	b = append(b, R{[]int{1}})
+	ch := Changes(a, b)
+	if len(ch) != 1 || ch[0].Kind != Added || ch[0].Path.String() != "[300]" {
+		t.Errorf("Changes = %v", ch)
+	}
+	b[0].V = append([]int{-1}, b[0].V[:n-1]...)
+	b[n-1] = R{[]int{2}}
+	if ch := Changes(a, b); len(ch) == 0 {
+		t.Errorf("Changes found no differences")
+	}
+}
+
+func TestDiffStrings(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	a := strings.Join(lines, "\n") + "\n"
+	lines[1] = "changed 2"
+	lines = append(lines[:10], append([]string{"new"}, lines[10:19]...)...)
+	b := strings.Join(lines, "\n") + "\n"
+	long := strings.Repeat("x", 40)
+
+	c := NewConfig()
+	c.DiffStrings = true
+	for _, tt := range []difftest{
+		{"hello world", "hello there", []string{`"hello world" != "hello there" (first difference at rune 6)`}},
+		{long + "abc" + long, long + "abd" + long, []string{
+			`..."xxxxxxxxxxxxxxabcxxxxxxxxxxxxxxx"... != ..."xxxxxxxxxxxxxxabdxxxxxxxxxxxxxxx"... (first difference at rune 42)`,
+		}},
+		{"a\nb", "a\nb\n", []string{"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of string\n+b"}},
+		{"", "a\n", []string{"@@ -0,0 +1,1 @@\n+a"}},
+		{struct{ T string }{a}, struct{ T string }{b}, []string{`T: @@ -1,5 +1,5 @@
+ line 1
+-line 2
++changed 2
+ line 3
+ line 4
+ line 5
+@@ -8,6 +8,7 @@
+ line 8
+ line 9
+ line 10
++new
+ line 11
+ line 12
+ line 13
+@@ -17,4 +18,3 @@
+ line 17
+ line 18
+ line 19
+-line 20`}},
+	} {
+		diffdiff(t, c.Diff(tt.a, tt.b), tt.exp)
+	}
+
+	c.DiffStringsMinLen = 20
+	diffdiff(t, c.Diff("hello world", "hello there"), []string{`"hello world" != "hello there"`})
+	diffdiff(t, c.Diff(long+"abc", long+"abd"), []string{
+		`..."xxxxxxxxxxxxxxabc" != ..."xxxxxxxxxxxxxxabd" (first difference at rune 42)`,
+	})
+	diffdiff(t, c.Diff("a\nb", "a\nc"), []string{"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of string\n+c\n\\ No newline at end of string"})
+
+	c.DiffContext = 0
+	diffdiff(t, c.Diff(a, b), []string{"@@ -2,1 +2,1 @@\n-line 2\n+changed 2\n@@ -10,0 +11,1 @@\n+new\n@@ -20,1 +20,0 @@\n-line 20"})
+}
+
+func TestDiffIgnore(t *testing.T) {
+	type Item struct {
+		Name      string
+		UpdatedAt int
+		Note      string `pretty:",nodiff"`
+		secret    int
+	}
+	type Doc struct {
+		Items []Item
+		Attrs map[string]int
+	}
+	a := Doc{
+		Items: []Item{{"a", 1, "x", 1}, {"b", 2, "y", 2}},
+		Attrs: map[string]int{"ts": 1, "n": 1},
+	}
+	b := Doc{
+		Items: []Item{{"a", 3, "z", 1}, {"c", 4, "y", 3}},
+		Attrs: map[string]int{"ts": 2, "n": 2},
+	}
+
+	c := NewConfig()
+	diffdiff(t, c.Diff(a, b), []string{
+		`Items[0].UpdatedAt: 1 != 3`,
+		`Items[1].Name: "b" != "c"`,
+		`Items[1].UpdatedAt: 2 != 4`,
+		`Items[1].secret: 2 != 3`,
+		`Attrs["n"]: 1 != 2`,
+		`Attrs["ts"]: 1 != 2`,
+	})
+
+	c.IgnoreUnexported = true
+	c.IgnorePaths = []string{"Items[*].UpdatedAt", `.Attrs["ts"]`}
+	diffdiff(t, c.Diff(a, b), []string{
+		`Items[1].Name: "b" != "c"`,
+		`Attrs["n"]: 1 != 2`,
+	})
+
+	c.IgnorePaths = []string{"*[*].Name", "Attrs[*]"}
+	c.IgnoreFields = []string{"UpdatedAt"}
+	diffdiff(t, c.Diff(a, b), nil)
+
+	type Cached struct {
+		N     int
+		Cache []int `pretty:"-"`
+	}
+	diffdiff(t, NewConfig().Diff(Cached{1, []int{1}}, Cached{1, []int{2}}), nil)
+
+	// elements are aligned ignoring the same paths
+	type Row struct {
+		Name      string
+		UpdatedAt int
+	}
+	c = NewConfig()
+	c.IgnorePaths = []string{"[*].UpdatedAt"}
+	diffdiff(t, c.Diff([]Row{{"a", 1}, {"b", 1}}, []Row{{"x", 2}, {"a", 2}, {"b", 2}}), []string{
+		`[0]: (missing) != pretty.Row{Name:"x", UpdatedAt:2}`,
+	})
+}
+
+func TestPatternSegments(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		exp     []string
+	}{
+		{"", nil},
+		{".", nil},
+		{"A.B", []string{"A", "B"}},
+		{".Items[*].UpdatedAt", []string{"Items", "[*]", "UpdatedAt"}},
+		{`M["a]b"][2]`, []string{"M", `["a]b"]`, "[2]"}},
+	} {
+		if got := patternSegments(tt.pattern); !reflect.DeepEqual(got, tt.exp) {
+			t.Errorf("patternSegments(%q) = %q want %q", tt.pattern, got, tt.exp)
+		}
+	}
+}
+
+func TestDiffFloat(t *testing.T) {
+	nan := math.NaN()
+	next := math.Nextafter(1, 2)
+	next32 := math.Nextafter32(1, 2)
+	x, y := 0.1, 0.2
+
+	c := NewConfig()
+	for _, tt := range []difftest{
+		{x + y, 0.3, []string{"0.30000000000000004 != 0.3"}},
+		{nan, nan, []string{"NaN != NaN"}},
+		{1.0, next, []string{"1 != 1.0000000000000002"}},
+		{complex(nan, 1), complex(nan, 1), []string{"(NaN+1i) != (NaN+1i)"}},
+	} {
+		diffdiff(t, c.Diff(tt.a, tt.b), tt.exp)
+	}
+
+	c.NaNEqual = true
+	c.FloatULPs = 2
+	for _, tt := range []difftest{
+		{nan, nan, nil},
+		{nan, 1.0, []string{"NaN != 1"}},
+		{1.0, next, nil},
+		{float32(1), next32, nil},
+		{float32(1), math.Nextafter32(next32, 2), nil},
+		{float32(1), float32(1.0001), []string{"1 != 1.000100016593933"}},
+		{-0.0, math.Copysign(0, -1), nil},
+		{math.Inf(1), math.MaxFloat64, []string{"+Inf != 1.7976931348623157e+308"}},
+		{complex(nan, 1), complex(nan, next), nil},
+		{x + y, 0.3, nil},
+		{1e-20, -1e-20, []string{"1e-20 != -1e-20"}},
+	} {
+		diffdiff(t, c.Diff(tt.a, tt.b), tt.exp)
+	}
+
+	c.FloatULPs = 0
+	c.FloatAbsTol = 1e-9
+	diffdiff(t, c.Diff(1e-20, -1e-20), nil)
+	diffdiff(t, c.Diff(1e6, 1e6+1), []string{"1e+06 != 1.000001e+06"})
+
+	c.FloatAbsTol = 0
+	c.FloatRelTol = 1e-6
+	diffdiff(t, c.Diff(1e6, 1e6+1), nil)
+	diffdiff(t, c.Diff(complex(1, 1e6), complex(1, 1e6+1)), nil)
+	diffdiff(t, c.Diff(complex(1, 1e6), complex(1.1, 1e6)), []string{"(1+1e+06i) != (1.1+1e+06i)"})
+}
+
+type version struct{ major, minor int }
+
+func (v version) Equal(w version) bool { return v.major == w.major }
+
+func TestDiffEqual(t *testing.T) {
+	loc := time.FixedZone("X", 3600)
+	t0 := time.Now()
+	t1 := t0.Round(0).In(loc)
+
+	c := NewConfig()
+	type T struct {
+		At time.Time
+		v  version
+	}
+	for _, tt := range []difftest{
+		{t0, t1, nil},
+		{T{t0, version{1, 0}}, T{t1, version{1, 2}}, nil},
+		{T{v: version{1, 0}}, T{v: version{2, 0}}, []string{"v: pretty.version{major:1, minor:0} != pretty.version{major:2, minor:0}"}},
+		{&t0, &t1, nil},
+		{[]time.Time{t0}, []time.Time{t1}, nil},
+	} {
+		diffdiff(t, c.Diff(tt.a, tt.b), tt.exp)
+	}
+
+	c.UseEqual = false
+	if d := c.Diff(t0, t1); len(d) == 0 {
+		t.Errorf("Diff without UseEqual found no difference between %v and %v", t0, t1)
+	}
+	if d := c.Diff(version{1, 0}, version{1, 2}); len(d) != 1 || d[0] != "minor: 0 != 2" {
+		t.Errorf("Diff without UseEqual = %q", d)
+	}
+
+	c.UseEqual = true
+	c.RegisterComparer(reflect.TypeOf(version{}), func(a, b reflect.Value) bool {
+		return a.Interface().(version).minor == b.Interface().(version).minor
+	})
+	diffdiff(t, c.Diff(version{1, 0}, version{2, 0}), nil)
+	diffdiff(t, c.Diff(T{v: version{1, 0}}, T{v: version{1, 1}}), []string{
+		"v: pretty.version{major:1, minor:0} != pretty.version{major:1, minor:1}",
+	})
+	c.RegisterComparer(reflect.TypeOf(version{}), nil)
+	diffdiff(t, c.Diff(version{1, 0}, version{2, 0}), []string{
+		"pretty.version{major:1, minor:0} != pretty.version{major:2, minor:0}",
+	})
+}
+
+func TestDiffUnordered(t *testing.T) {
+	type Perms []string
+	type User struct {
+		Tags  []string `pretty:",unordered"`
+		Perms *Perms   `pretty:",unordered"`
+		Ports []int
+	}
+	a := User{
+		Tags:  []string{"a", "b", "b", "c"},
+		Perms: &Perms{"read", "write"},
+		Ports: []int{80, 443},
+	}
+	b := User{
+		Tags:  []string{"c", "b", "a", "d"},
+		Perms: &Perms{"write", "read"},
+		Ports: []int{443, 80},
+	}
+
+	c := NewConfig()
+	diffdiff(t, c.Diff(a, b), []string{
+		`Tags[2]: "b" != (missing)`,
+		`Tags[3]: (missing) != "d"`,
+		`Ports[0]: 80 != (missing)`,
+		`Ports[1]: (missing) != 80`,
+	})
+
+	c.UnorderedTypes = []reflect.Type{reflect.TypeOf([]int(nil))}
+	diffdiff(t, c.Diff(a, b), []string{
+		`Tags[2]: "b" != (missing)`,
+		`Tags[3]: (missing) != "d"`,
+	})
+	diffdiff(t, c.Diff([][]int{{1, 2}, {3}}, [][]int{{3}, {2, 1}}), []string{
+		`[0]: []int{1, 2} != (missing)`,
+		`[1]: (missing) != []int{2, 1}`,
+	})
+
+	// the tag only applies to the field's slice, not those within it
+	type Nested struct {
+		Sets [][]int `pretty:",unordered"`
+	}
+	diffdiff(t, NewConfig().Diff(Nested{[][]int{{1, 2}, {3}}}, Nested{[][]int{{3}, {2, 1}}}), []string{
+		`Sets[0]: []int{1, 2} != (missing)`,
+		`Sets[1]: (missing) != []int{2, 1}`,
+	})
+
+	c.UnorderedSlices = true
+	diffdiff(t, c.Diff([][]int{{1, 2}, {3}}, [][]int{{3}, {2, 1}}), nil)
+	diffdiff(t, c.Diff([]*int{new(int)}, []*int{new(int)}), nil)
+	diffdiff(t, c.Diff([2]float64{0.5, 1}, [2]float64{1, 0.25}), []string{
+		`[0]: 0.5 != (missing)`,
+		`[1]: (missing) != 0.25`,
+	})
+
+	// too many pairs to compare leaves the elements compared in order
+	one, two := 1, 2
+	c.MaxDiffAlign = 3
+	diffdiff(t, c.Diff([]*int{&one, &two}, []*int{&two, &one}), []string{`[0]: 1 != 2`, `[1]: 2 != 1`})
+	c.MaxDiffAlign = 4
+	diffdiff(t, c.Diff([]*int{&one, &two}, []*int{&two, &one}), nil)
+
+	type P struct{ P *int }
+	var a1, b1 []P
+	for i := 0; i < 5000; i++ {
+		a1 = append(a1, P{new(int)})
+		b1 = append([]P{{new(int)}}, b1...)
+	}
+	b1 = append(b1, P{&one})
+	c = NewConfig()
+	c.UnorderedSlices = true
+	diffdiff(t, c.Diff(a1, b1), []string{"[5000]: (missing) != pretty.P{\n    P:  &int(1),\n}"})
+}
+
+func TestDiffKeyed(t *testing.T) {
+	type User struct {
+		ID    int `pretty:"id,key"`
+		Email string
+	}
+	type Group struct {
+		Name string
+		Size int
+	}
+	a := []User{{1, "a@x"}, {42, "a"}, {7, "c"}}
+	b := []User{{42, "b"}, {1, "a@x"}, {9, "d"}}
+
+	c := NewConfig()
+	diffdiff(t, c.Diff(a, b), []string{
+		`[id=42].Email: "a" != "b"`,
+		`[id=7]: pretty.User{ID:7, Email:"c"} != (missing)`,
+		`[id=9]: (missing) != pretty.User{ID:9, Email:"d"}`,
+	})
+	diffdiff(t, c.Diff(struct{ Users []*User }{[]*User{{42, "a"}}}, struct{ Users []*User }{[]*User{{42, "b"}}}), []string{
+		`Users[id=42].Email: "a" != "b"`,
+	})
+	diffdiff(t, c.Diff([]*User{{42, "a"}, nil}, []*User{{42, "b"}, nil}), []string{
+		`[0].Email: "a" != "b"`,
+	})
+
+	ch := c.Changes(a, b)
+	if len(ch) != 3 || ch[1].Kind != Removed || ch[1].Path[0].Index != 2 || ch[2].Path[0].Index != 2 {
+		t.Errorf("Changes = %v", ch)
+	}
+
+	// keys that can't be hashed leave the elements matched by position
+	type Any struct {
+		K interface{} `pretty:",key"`
+		V int
+	}
+	diffdiff(t, c.Diff([]Any{{[]int{1}, 1}, {2, 2}}, []Any{{[]int{1}, 1}, {2, 3}}), []string{
+		`[1].V: 2 != 3`,
+	})
+	diffdiff(t, c.Diff([]Any{{1, 1}, {"1", 2}, {1, 3}}, []Any{{"1", 2}, {1, 4}, {1, 3}}), []string{
+		`[K=1].V: 1 != 4`,
+	})
+
+	c.KeyFields = map[reflect.Type]string{reflect.TypeOf(Group{}): "Name"}
+	diffdiff(t, c.Diff([]Group{{"x", 1}, {"y", 2}}, []Group{{"y", 3}, {"x", 1}}), []string{
+		`[Name="y"].Size: 2 != 3`,
+	})
+	c.IgnorePaths = []string{"[*].Size"}
+	diffdiff(t, c.Diff([]Group{{"x", 1}, {"y", 2}}, []Group{{"y", 3}, {"x", 1}}), nil)
+}
+
+func TestKeyDiff(t *testing.T) {
+	nan := math.NaN()
+	for _, tt := range []struct{ a, b interface{} }{
+		{map[int]int{1: 1, 2: 2, 3: 3}, map[int]int{2: 2, 4: 4}},
+		{map[float64]int{nan: 1, 1: 1}, map[float64]int{nan: 1, 1: 1}},
+		{map[interface{}]int{1: 1, "1": 1, [1]int{1}: 1}, map[interface{}]int{int64(1): 1, "1": 1, [1]int{1}: 1}},
+		{map[struct{ a, b int }]int{{1, 2}: 1}, map[struct{ a, b int }]int{{1, 2}: 1, {2, 1}: 1}},
+	} {
+		a, b := reflect.ValueOf(tt.a).MapKeys(), reflect.ValueOf(tt.b).MapKeys()
+		ak, both, bk := keyDiff(a, b)
+		pak, pboth, pbk := keyDiffPairs(a, b)
+		if len(ak) != len(pak) || len(both) != len(pboth) || len(bk) != len(pbk) {
+			t.Errorf("keyDiff(%v, %v) found %d, %d, %d keys want %d, %d, %d",
+				tt.a, tt.b, len(ak), len(both), len(bk), len(pak), len(pboth), len(pbk))
+		}
+	}
+}
+
+func benchmarkDiffMap(b *testing.B, n int, hidden bool) {
+	type T struct{ m map[int]int }
+	x, y := T{map[int]int{}}, T{map[int]int{}}
+	for i := 0; i < n; i++ {
+		x.m[i] = i
+		y.m[i+n/10] = i
+	}
+	var xv, yv interface{} = x.m, y.m
+	if hidden {
+		xv, yv = x, y
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Diff(xv, yv)
+	}
+}
+
+func BenchmarkDiffMap(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("map/%d", n), func(b *testing.B) { benchmarkDiffMap(b, n, false) })
+		b.Run(fmt.Sprintf("unexported/%d", n), func(b *testing.B) { benchmarkDiffMap(b, n, true) })
+	}
+}
+
+// BenchmarkKeyDiff compares looking keys up in a Go map with comparing
+// every pair of them, as keyDiff falls back on.
+func BenchmarkKeyDiff(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		x, y := map[int]int{}, map[int]int{}
+		for i := 0; i < n; i++ {
+			x[i] = i
+			y[i+n/10] = i
+		}
+		xk, yk := reflect.ValueOf(x).MapKeys(), reflect.ValueOf(y).MapKeys()
+		b.Run(fmt.Sprintf("hashed/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				keyDiff(xk, yk)
+			}
+		})
+		b.Run(fmt.Sprintf("pairs/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				keyDiffPairs(xk, yk)
+			}
+		})
+	}
+}
+
+func TestDiffNaNKeys(t *testing.T) {
+	nan := math.NaN()
+	m := map[float64]string{nan: "x", 1: "y"}
+	diffdiff(t, Diff(m, m), []string{
+		`[NaN]: "x" != (missing)`,
+		`[NaN]: (missing) != "x"`,
+	})
+}
+
+func TestDiffMapOrder(t *testing.T) {
+	a := map[interface{}]string{"b": "x", "a": "x", 3: "x", 1: "x", 2.5: "x", "gone": "x", 0: "x"}
+	b := map[interface{}]string{"b": "y", "a": "y", 3: "y", 1: "y", 2.5: "y", "new": "x", -1: "x"}
+	exp := []string{
+		`[0]: "x" != (missing)`,
+		`["gone"]: "x" != (missing)`,
+		`[2.5]: "x" != "y"`,
+		`[1]: "x" != "y"`,
+		`[3]: "x" != "y"`,
+		`["a"]: "x" != "y"`,
+		`["b"]: "x" != "y"`,
+		`[-1]: (missing) != "x"`,
+		`["new"]: (missing) != "x"`,
+	}
+	for i := 0; i < 10; i++ {
+		if got := Diff(a, b); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("Diff = %q want %q", got, exp)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package pretty
+
+import (
+	"reflect"
+)
+
+// A Comparer reports whether a and b, values of a type it has been
+// registered for (see Config.RegisterComparer), are equal.  Like
+// Renderers, Comparers are never called for nil pointers, maps or slices,
+// nor for values pretty can't read with v.Interface().
+type Comparer func(a, b reflect.Value) bool
+
+// RegisterComparer arranges for Diff to compare values of type t, wherever
+// they appear, with eq rather than by their contents, reporting them as
+// one difference if eq returns false.  It takes precedence over an Equal
+// method (see Config.UseEqual).  A nil eq removes the registration for t.
+//
+// RegisterComparer must not be called while c is being used to diff.
+func (c *Config) RegisterComparer(t reflect.Type, eq Comparer) {
+	if eq == nil {
+		delete(c.comparers, t)
+		return
+	}
+	if c.comparers == nil {
+		c.comparers = make(map[reflect.Type]Comparer)
+	}
+	c.comparers[t] = eq
+}
+
+// RegisterComparer is like Config.RegisterComparer but for the default
+// configuration used by the package-level functions.
+func RegisterComparer(t reflect.Type, eq Comparer) {
+	defaultConfig.RegisterComparer(t, eq)
+}
+
+// compare compares av and bv, values of the same type, with the Comparer
+// registered for it or its Equal method.  It returns false for ok if
+// there is neither, or they can't be used, so av and bv are to be
+// compared by their contents, as they are if the comparison panics.
+func (c *Config) compare(av, bv reflect.Value) (equal, ok bool) {
+	t := av.Type()
+	switch t.Kind() {
+	case reflect.Interface:
+		return false, false // the dynamic values get their turn
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if av.IsNil() || bv.IsNil() {
+			return false, false
+		}
+	}
+	eq, ok := c.comparers[t]
+	if !ok && c.UseEqual {
+		if m, found := t.MethodByName("Equal"); found && isEqualMethod(m, t) {
+			eq, ok = callEqual(m), true
+		}
+	}
+	if !ok {
+		return false, false
+	}
+	a, aok := interfaceOf(av)
+	b, bok := interfaceOf(bv)
+	if !aok || !bok {
+		return false, false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			equal, ok = false, false
+		}
+	}()
+	return eq(reflect.ValueOf(a), reflect.ValueOf(b)), true
+}
+
+// isEqualMethod reports whether m, a method of type t, has the signature
+// func(t) bool.
+func isEqualMethod(m reflect.Method, t reflect.Type) bool {
+	mt := m.Type
+	return mt.NumIn() == 2 && mt.In(1) == t && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Bool
+}
+
+// callEqual returns a Comparer calling the Equal method m.
+func callEqual(m reflect.Method) Comparer {
+	return func(a, b reflect.Value) bool {
+		return m.Func.Call([]reflect.Value{a, b})[0].Bool()
+	}
+}
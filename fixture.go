@@ -0,0 +1,86 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+)
+
+// WriteFixture writes to w a complete, gofmt'd Go source file declaring a
+// variable called name that holds a copy of x, for seeding table-driven
+// tests with captured values.  The file belongs to the package with the
+// import path pkgPath, the last element of which is the package name in
+// the package clause (eg: "example.com/app/store" or, for an external
+// test package, "example.com/app/store_test").  Types from that package
+// are written unqualified, and their unexported fields and types can be
+// used, all the other packages referred to are imported.
+//
+// As with Literal, if some part of x can't be expressed the source is
+// still written, with nil or zero values standing in for those parts, and
+// the returned error is a *LiteralError listing them.
+func (c *Config) WriteFixture(w io.Writer, pkgPath, name string, x interface{}) error {
+	pkg := path.Base(pkgPath)
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("pretty: invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("pretty: invalid variable name %q", name)
+	}
+	lc := *c
+	lc.Humanize = false
+	lc.Compilable = true
+	var expr bytes.Buffer
+	lit := &literalState{pkgPath: pkgPath}
+	lc.render(&expr, reflect.ValueOf(x), true, lit)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by pretty.WriteFixture.\n\npackage %s\n", pkg)
+	if len(lit.imports) > 0 {
+		paths := make([]string, 0, len(lit.imports))
+		for p := range lit.imports {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		named := make(map[string]string)
+		fmt.Fprintf(&src, "\nimport (\n")
+		for _, p := range paths {
+			n := lit.imports[p]
+			if other, ok := named[n]; ok {
+				lit.problems = append(lit.problems, fmt.Sprintf("packages %q and %q are both named %s", other, p, n))
+			}
+			named[n] = p
+			if n == path.Base(p) {
+				fmt.Fprintf(&src, "\t%q\n", p)
+			} else {
+				fmt.Fprintf(&src, "\t%s %q\n", n, p)
+			}
+		}
+		fmt.Fprintf(&src, ")\n")
+	}
+	fmt.Fprintf(&src, "\nvar %s = %s\n", name, expr.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		// not expected, but the unformatted source is still of use
+		out = src.Bytes()
+		lit.problems = append(lit.problems, err.Error())
+	}
+	if _, err := w.Write(out); err != nil {
+		return err
+	}
+	if len(lit.problems) > 0 {
+		return &LiteralError{lit.problems}
+	}
+	return nil
+}
+
+// WriteFixture is like Config.WriteFixture but uses the default
+// configuration.
+func WriteFixture(w io.Writer, pkgPath, name string, x interface{}) error {
+	return defaultConfig.WriteFixture(w, pkgPath, name, x)
+}
@@ -0,0 +1,142 @@
+package pretty
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteFixture(t *testing.T) {
+	v := map[string]interface{}{
+		"at":     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"big":    big.NewInt(42),
+		"nan":    math.NaN(),
+		"hidden": &T{1, 2},
+		"str":    Str("s"),
+	}
+	for _, tt := range []struct {
+		pkgPath  string
+		s        string
+		problems []string
+	}{
+		{"github.com/dvln/pretty", `// Code generated by pretty.WriteFixture.
+
+package pretty
+
+import (
+	"math"
+	"math/big"
+	"time"
+)
+
+var captured = map[string]interface{}{
+	"at":     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	"big":    func() *big.Int { v, _ := new(big.Int).SetString("42", 10); return v }(),
+	"hidden": &T{x: 1, y: 2},
+	"nan":    math.NaN(),
+	"str":    Str("s"),
+}
+`, nil},
+		{"github.com/dvln/pretty_test", `// Code generated by pretty.WriteFixture.
+
+package pretty_test
+
+import (
+	"github.com/dvln/pretty"
+	"math"
+	"math/big"
+	"time"
+)
+
+var captured = map[string]interface{}{
+	"at":     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	"big":    func() *big.Int { v, _ := new(big.Int).SetString("42", 10); return v }(),
+	"hidden": &pretty.T{},
+	"nan":    math.NaN(),
+	"str":    pretty.Str("s"),
+}
+`, []string{
+			`.["hidden"].x: unexported field of pretty.T can't be set`,
+			`.["hidden"].y: unexported field of pretty.T can't be set`,
+		}},
+	} {
+		var buf bytes.Buffer
+		err := WriteFixture(&buf, tt.pkgPath, "captured", v)
+		if s := buf.String(); s != tt.s {
+			t.Errorf("WriteFixture(%q):\n%s\nwant:\n%s", tt.pkgPath, s, tt.s)
+		}
+		var problems []string
+		if le, ok := err.(*LiteralError); ok {
+			problems = le.Problems
+		} else if err != nil {
+			t.Errorf("WriteFixture(%q) error = %v", tt.pkgPath, err)
+		}
+		if strings.Join(problems, "\n") != strings.Join(tt.problems, "\n") {
+			t.Errorf("WriteFixture(%q) problems = %q want %q", tt.pkgPath, problems, tt.problems)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "fixture.go", buf.Bytes(), 0); err != nil {
+			t.Errorf("WriteFixture(%q) doesn't parse: %v", tt.pkgPath, err)
+		}
+	}
+}
+
+// TestWriteFixtureTypes checks that a generated fixture compiles, using
+// only standard library types so that it can be type-checked on its own.
+func TestWriteFixtureTypes(t *testing.T) {
+	u, _ := url.Parse("https://example.com/a?b=c")
+	v := map[string]interface{}{
+		"at":    time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("X", 3600)),
+		"big":   []*big.Int{big.NewInt(42), nil},
+		"float": big.NewFloat(1.5),
+		"rat":   big.NewRat(1, 3),
+		"nan":   []float64{math.NaN(), math.Inf(-1)},
+		"ip":    net.ParseIP("10.0.0.1"),
+		"addr":  netip.MustParseAddr("::1"),
+		"url":   u,
+		"times": map[string][]time.Duration{"a": {time.Second, 0}},
+		"ptr":   &[]*int{new(int)},
+		"type":  reflect.TypeOf(time.Time{}),
+		"struct": struct {
+			At  time.Time
+			Dur *time.Duration
+		}{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), new(time.Duration)},
+	}
+	var buf bytes.Buffer
+	if err := WriteFixture(&buf, "example.com/fixture", "captured", v); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fixture.go", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("example.com/fixture", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("WriteFixture output doesn't compile: %v\n%s", err, buf.Bytes())
+	}
+}
+
+func TestWriteFixtureNames(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFixture(&buf, "example.com/my-pkg", "v", 1); err == nil {
+		t.Errorf("WriteFixture accepted an invalid package name")
+	}
+	if err := WriteFixture(&buf, "example.com/pkg", "a b", 1); err == nil {
+		t.Errorf("WriteFixture accepted an invalid variable name")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteFixture wrote %q for invalid names", buf.String())
+	}
+}
@@ -0,0 +1,67 @@
+package pretty
+
+import (
+	"math"
+)
+
+// floatEqual reports whether Diff treats a and b, floats of the given
+// size in bits, as equal (see Config.FloatAbsTol).
+func (c *Config) floatEqual(a, b float64, bits int) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return c.NaNEqual && math.IsNaN(a) && math.IsNaN(b)
+	}
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+	d := math.Abs(a - b)
+	if c.FloatAbsTol > 0 && d <= c.FloatAbsTol {
+		return true
+	}
+	if c.FloatRelTol > 0 && d <= c.FloatRelTol*math.Max(math.Abs(a), math.Abs(b)) {
+		return true
+	}
+	return c.FloatULPs > 0 && ulps(a, b, bits) <= c.FloatULPs
+}
+
+// complexEqual reports whether Diff treats a and b, complex numbers of
+// the given size in bits, as equal, comparing their parts as floats.
+func (c *Config) complexEqual(a, b complex128, bits int) bool {
+	return c.floatEqual(real(a), real(b), bits/2) && c.floatEqual(imag(a), imag(b), bits/2)
+}
+
+// ulps returns how many representable floats of the given size in bits
+// apart a and b, finite numbers, are.
+func ulps(a, b float64, bits int) uint64 {
+	var oa, ob int64
+	if bits == 32 {
+		oa, ob = int64(ordered32(float32(a))), int64(ordered32(float32(b)))
+	} else {
+		oa, ob = ordered64(a), ordered64(b)
+	}
+	if oa < ob {
+		oa, ob = ob, oa
+	}
+	return uint64(oa) - uint64(ob)
+}
+
+// ordered64 maps f to an integer such that adjacent floats map to adjacent
+// integers, with -0 and +0 both mapping to 0.
+func ordered64(f float64) int64 {
+	i := int64(math.Float64bits(f))
+	if i < 0 {
+		i = math.MinInt64 - i
+	}
+	return i
+}
+
+// ordered32 is like ordered64 for float32s.
+func ordered32(f float32) int32 {
+	i := int32(math.Float32bits(f))
+	if i < 0 {
+		i = math.MinInt32 - i
+	}
+	return i
+}
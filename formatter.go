@@ -8,39 +8,14 @@ import (
 	"strings"
 	"text/tabwriter"
 	"unicode"
+	"unicode/utf8"
+	"unsafe"
 
 	"github.com/dvln/text"
 )
 
-// outputIndentLevel is covered in the SetOutputIndentLevel() func header
-var outputIndentLevel = 4
-
-// humanize is covered in the SetHumanize() function header
-var humanize = false
-
-// outputPrefixStr is covered in the SetOutputPrefixStr() function header
-var outputPrefixStr = ""
-
-// newlineAfterItems allows one to make the humanize output insert a blank
-// line between entries in a somewhat sensical way... normally that is off.
-var newlineAfterItems = false
-
-// sawCloseBracketLast is incremented when a json-like '}' is seen in
-// the output and then set back to 0 when anything else is seen (if one
-// has 2 or 3 '}' chars in a row it'll increment til a non '}' char is
-// seen... could be used to add spacing between items
-var sawCloseBracketLast = 0
-
-// currOutputLine only kicks on in 'humanize' active (set to true) mode, it
-// examines all output being dumped and tracks what is on the current line
-// of output and will clear that line when \n goes across the output.  This
-// is used to decide if a carriage return + indent is needed when in
-// human friendly output mode (if we see a ':' in the current line of output
-// it means a "<key>:" header has been printed and a newline/indent is needed
-// for the multi-line data to follow)
-var currOutputLine = ""
-
 type formatter struct {
+	c     *Config
 	v     reflect.Value
 	force bool
 	quote bool
@@ -56,7 +31,13 @@ type formatter struct {
 // format x according to the usual rules of package fmt.
 // In particular, if x satisfies fmt.Formatter, then x.Format will be called.
 func Formatter(x interface{}) (f fmt.Formatter) {
-	return formatter{v: reflect.ValueOf(x), quote: true}
+	return defaultConfig.Formatter(x)
+}
+
+// Formatter is like the package-level Formatter but formats x using
+// the options in c.
+func (c *Config) Formatter(x interface{}) (f fmt.Formatter) {
+	return formatter{c: c, v: reflect.ValueOf(x), quote: true}
 }
 
 func (fo formatter) String() string {
@@ -67,7 +48,7 @@ func (fo formatter) passThrough(f fmt.State, c rune) {
 	s := "%"
 	for i := 0; i < 128; i++ {
 		if f.Flag(i) {
-			s += string(i)
+			s += string(rune(i))
 		}
 	}
 	if w, ok := f.Width(); ok {
@@ -84,14 +65,14 @@ func (fo formatter) passThrough(f fmt.State, c rune) {
 // be used when dumping output via pretty (defaults to 4 to start),
 // see SetOutputIndentLevel() to adjust.
 func OutputIndentLevel() int {
-	return outputIndentLevel
+	return defaultConfig.OutputIndentLevel
 }
 
 // SetOutputIndentLevel can be used to adjust the step-wise indent for
 // structure representations that are printed.  Give an integer number
 // of spaces (recommended 2 or 4, default is 4 to start)
 func SetOutputIndentLevel(indent int) {
-	outputIndentLevel = indent
+	defaultConfig.OutputIndentLevel = indent
 }
 
 // Humanize will return the current true/false state of if "humanizing" of
@@ -99,7 +80,7 @@ func SetOutputIndentLevel(indent int) {
 // 'pretty' was originally set up for, a go-like structure w/details.  See
 //  SetHumanize() to flip it on (and see what it does).
 func Humanize() bool {
-	return humanize
+	return defaultConfig.Humanize
 }
 
 // SetHumanize can be used to flip on a more "Humanistic" form of output
@@ -114,72 +95,208 @@ func Humanize() bool {
 // the structure and then dump output easily in JSON (via json marshal) or
 // dump the same struct to human friendly text for users.
 func SetHumanize(b bool) {
-	humanize = b
+	defaultConfig.Humanize = b
 }
 
 // NewlineAfterItems will return the current true/false state of if newlines
 // after each item is desired or not.  By default this is off.
 func NewlineAfterItems() bool {
-	return newlineAfterItems
+	return defaultConfig.NewlineAfterItems
 }
 
 // SetNewlineAfterItems can be used to adjust humanistic output format so
 // that there's an empty line between items that are printed.  By default
 // it's off but this can be used to flip that on by setting to true.
 func SetNewlineAfterItems(b bool) {
-	newlineAfterItems = b
+	defaultConfig.NewlineAfterItems = b
 }
 
 // OutputPrefixStr returns the current overall text prefix string, see
 // the SetOutputPrefixStr() routine to set it.
 func OutputPrefixStr() string {
-	return outputPrefixStr
+	return defaultConfig.OutputPrefixStr
 }
 
 // SetOutputPrefixStr sets the output prefix string to the given string
 func SetOutputPrefixStr(s string) {
-	outputPrefixStr = s
+	defaultConfig.OutputPrefixStr = s
 }
 
 func (fo formatter) Format(f fmt.State, c rune) {
 	if fo.force || c == 'v' && f.Flag('#') && f.Flag(' ') {
-		w := tabwriter.NewWriter(f, outputIndentLevel, outputIndentLevel, 1, ' ', 0)
-		p := &printer{tw: w, Writer: w, visited: make(map[visit]int)}
-		p.printValue(fo.v, true, fo.quote)
-		w.Flush()
+		var lit *literalState
+		if fo.c.Compilable && !fo.c.Humanize {
+			lit = new(literalState)
+		}
+		fo.c.render(f, fo.v, fo.quote, lit)
 		return
 	}
 	fo.passThrough(f, c)
 }
 
+// render pretty-prints v to w according to the options in c, lit collects
+// the problems found when producing compilable output (it is nil if that
+// isn't wanted).
+func (c *Config) render(w io.Writer, v reflect.Value, quote bool, lit *literalState) {
+	tw := tabwriter.NewWriter(w, c.OutputIndentLevel, c.OutputIndentLevel, 1, ' ', 0)
+	p := &printer{c: c, tw: tw, Writer: tw, line: new(lineState), lit: lit}
+	v = addressable(v)
+	if c.LabelShared && !c.Humanize && lit == nil {
+		p.shared = c.scanShared(v)
+	}
+	p.printValue(v, true, quote)
+	tw.Flush()
+}
+
+// addressable returns an addressable copy of v, if it holds unexported
+// struct fields (not behind a pointer) and isn't addressable already, so
+// that they can be read as interfaces by interfaceOf.  That is only done
+// to hand them to a Renderer or a method (see Config.CallMethods).
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() || !v.CanInterface() || !hasUnexported(v.Type()) {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// hasUnexported reports whether values of type t hold unexported struct
+// fields directly, rather than through pointers, maps, slices or
+// interfaces (which lead to addressable values or can't be read anyway).
+func hasUnexported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return hasUnexported(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath != "" || hasUnexported(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type printer struct {
 	io.Writer
-	tw      *tabwriter.Writer
-	visited map[visit]int
-	depth   int
+	c         *Config
+	tw        *tabwriter.Writer
+	depth     int
+	line      *lineState
+	lit       *literalState
+	shared    *labelState
+	ancestors *ancestor
+	path      *step
+}
+
+// step is one step down from a value to one of its elements, linked back
+// up towards the value being printed so that the path to the element
+// being printed can be named (eg: ".Items[3].Name").
+type step struct {
+	up    *step
+	field string        // a struct field name, or
+	key   reflect.Value // a map key, or
+	index int           // an array or slice index
+}
+
+func (s *step) String() string {
+	switch {
+	case s == nil:
+		return ""
+	case s.field != "":
+		return s.up.String() + "." + s.field
+	case s.key.IsValid():
+		return fmt.Sprintf("%s[%#v]", s.up, s.key)
+	}
+	return fmt.Sprintf("%s[%d]", s.up, s.index)
+}
+
+// pathName names the value at the end of path s for messages, the value
+// being printed itself is named ".".
+func pathName(s *step) string {
+	name := s.String()
+	if !strings.HasPrefix(name, ".") {
+		name = "." + name
+	}
+	return name
+}
+
+// lineState holds the render-time state of humanized output, it is shared
+// by all the (copied and indented) printers used to render one value so
+// concurrent renderings never see each other's lines.
+type lineState struct {
+	// sawCloseBracketLast is incremented when a json-like '}' is seen in
+	// the output and then set back to 0 when anything else is seen (if one
+	// has 2 or 3 '}' chars in a row it'll increment til a non '}' char is
+	// seen... could be used to add spacing between items
+	sawCloseBracketLast int
+
+	// currOutputLine only kicks on in 'humanize' active (set to true) mode,
+	// it examines all output being dumped and tracks what is on the current
+	// line of output and will clear that line when \n goes across the
+	// output.  This is used to decide if a carriage return + indent is
+	// needed when in human friendly output mode (if we see a ':' in the
+	// current line of output it means a "<key>:" header has been printed
+	// and a newline/indent is needed for the multi-line data to follow)
+	currOutputLine string
 }
 
 func (p *printer) indent() *printer {
 	q := *p
-	q.tw = tabwriter.NewWriter(p.Writer, outputIndentLevel, outputIndentLevel, 1, ' ', 0)
+	q.tw = tabwriter.NewWriter(p.Writer, p.c.OutputIndentLevel, p.c.OutputIndentLevel, 1, ' ', 0)
 	q.Writer = text.NewIndentWriter(q.tw, []byte{'\t'})
 	return &q
 }
 
+// deeper returns a copy of p for printing the elements of a map, struct,
+// array or slice, one nesting level down.
+func (p *printer) deeper() *printer {
+	q := *p
+	q.depth++
+	return &q
+}
+
+// at returns a copy of p for printing the element of the current value
+// reached by s.
+func (p *printer) at(s step) *printer {
+	q := *p
+	s.up = p.path
+	q.path = &s
+	return &q
+}
+
+// within returns a copy of p for printing the value referred to by r,
+// with r added to the ancestors of the values within it.
+func (p *printer) within(r ref) *printer {
+	q := *p
+	q.ancestors = &ancestor{up: p.ancestors, r: r, path: p.path}
+	return &q
+}
+
+// typeString returns the name type t is printed with, this is its Go
+// source form when compilable output is being produced.
+func (p *printer) typeString(t reflect.Type) string {
+	if p.lit != nil {
+		return p.goType(t)
+	}
+	return t.String()
+}
+
 func (p *printer) printInline(v reflect.Value, x interface{}, showType bool) {
-	if showType && !humanize {
-		writeString(p, v.Type().String())
+	if showType && !p.c.Humanize {
+		p.writeString(p.typeString(v.Type()))
 		fmt.Fprintf(p, "(%#v)", x)
 	} else {
 		result := fmt.Sprintf("%#v", x)
-		if humanize && result != "" && strings.TrimSpace(result) == "" {
+		if p.c.Humanize && result != "" && strings.TrimSpace(result) == "" {
 			fmt.Fprintf(p, "\"%s\"", result)
 		} else {
 			fmt.Fprintf(p, "%s", result)
 		}
-		if humanize {
+		if p.c.Humanize {
 			lines := strings.Split(result, "\n")
-			currOutputLine = lines[len(lines)-1]
+			p.line.currOutputLine = lines[len(lines)-1]
 		}
 	}
 }
@@ -257,11 +374,11 @@ func isEmptyValue(v reflect.Value) bool {
 // carriage return and indent *but* if we're doing humanized output we
 // don't show the {}'s nor do we do the newlines', we want the items
 // to appear at the very left margin and show cleanly from there
-func indentNeeded() bool {
-	if !humanize {
+func (p *printer) indentNeeded() bool {
+	if !p.c.Humanize {
 		return true
 	}
-	if strings.ContainsRune(currOutputLine, ':') {
+	if strings.ContainsRune(p.line.currOutputLine, ':') {
 		return true
 	}
 	return false
@@ -299,32 +416,38 @@ func indentNeeded() bool {
 // indentNeeded() above as that handles the opening brackets and indent.
 // Note that some folks may want a blank line between each entry and
 // that can be done by counting the close brackets
-func newlineNeeded() bool {
-	if sawCloseBracketLast == 0 || (newlineAfterItems && sawCloseBracketLast == 2) {
+func (p *printer) newlineNeeded() bool {
+	if p.line.sawCloseBracketLast == 0 || (p.c.NewlineAfterItems && p.line.sawCloseBracketLast == 2) {
 		return true
 	}
 	return false
 }
 
-// printValue must keep track of already-printed pointer values to avoid
-// infinite recursion.
-type visit struct {
-	v   uintptr
-	typ reflect.Type
-}
-
 func (p *printer) printValue(v reflect.Value, showType, quote bool) {
-	if p.depth > 10 {
-		writeString(p, "!%v(DEPTH EXCEEDED)")
-		return
-	}
-
 	var expand bool
 
-	if humanize {
+	if p.c.Humanize {
 		quote = false
 		showType = false
 		expand = true
+	} else if p.lit != nil {
+		quote = true
+	}
+	if p.printRendered(v) || p.printMethod(v) {
+		return
+	}
+	if p.shared != nil && !p.c.elided(v, p.depth) && p.printLabel(v) {
+		return
+	}
+	if r, ok := refOf(v); ok {
+		if a := p.ancestors.find(r); a != nil {
+			p.printCycle(a)
+			return
+		}
+		p = p.within(r)
+	}
+	if p.c.MaxDepth > 0 && p.depth >= p.c.MaxDepth && p.printElided(v, showType) {
+		return
 	}
 	switch v.Kind() {
 	case reflect.Bool:
@@ -334,99 +457,125 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		p.printInline(v, v.Uint(), showType)
 	case reflect.Float32, reflect.Float64:
+		if p.lit != nil && p.printFloatLiteral(v) {
+			break
+		}
 		p.printInline(v, v.Float(), showType)
 	case reflect.Complex64, reflect.Complex128:
+		if p.lit != nil {
+			p.printComplexLiteral(v, showType)
+			break
+		}
 		fmt.Fprintf(p, "%#v", v.Complex())
 	case reflect.String:
-		p.fmtString(v.String(), quote)
+		s, more := v.String(), 0
+		if max := p.c.MaxStringLen; max > 0 && len(s) > max {
+			s = truncate(s, max)
+			more = v.Len() - len(s)
+		}
+		convert := p.lit != nil && showType && v.Type() != stringType
+		if convert {
+			p.writeString(p.typeString(v.Type()) + "(")
+		}
+		p.fmtString(s, quote)
+		if convert {
+			p.writeByte(')')
+		}
+		if more > 0 {
+			p.printMore(more, "byte")
+		}
 	case reflect.Map:
 		t := v.Type()
 		if showType {
-			if !humanize {
-				writeString(p, t.String())
+			if !p.c.Humanize {
+				p.writeString(p.typeString(t))
 			}
 		}
-		writeByte(p, '{') // '}' to balance the char
-		if nonzero(v) || humanize {
-			expand = !canInline(v.Type())
+		p.writeByte('{') // '}' to balance the char
+		if nonzero(v) || p.c.Humanize {
+			expand = !p.canInline(v.Type())
 			pp := p
 			if expand {
-				if indentNeeded() {
-					writeByte(p, '\n')
+				if p.indentNeeded() {
+					p.writeByte('\n')
 					pp = p.indent()
 				}
 			}
-			keys := v.MapKeys()
-			for i := 0; i < v.Len(); i++ {
+			pp = pp.deeper()
+			// untyped keys of interface keyed maps would lose their type
+			showTypeInKey := p.lit != nil && t.Key().Kind() == reflect.Interface
+			keys, vals := mapEntries(v, p.c.SortMapKeys)
+			if max := p.c.MaxMapEntries; max > 0 && len(keys) > max {
+				keys = keys[:max]
+			}
+			for i := 0; i < len(keys); i++ {
 				showTypeInStruct := true
-				if humanize {
+				if p.c.Humanize {
 					showTypeInStruct = false
 				}
 				k := keys[i]
-				mv := v.MapIndex(k)
-				pp.printValue(k, false, true)
-				writeByte(pp, ':')
+				mv := vals[i]
+				pp.printValue(k, showTypeInKey, true)
+				pp.writeByte(':')
 				if expand {
-					writeByte(pp, '\t')
+					pp.writeByte('\t')
 				}
-				if !humanize {
+				if !p.c.Humanize {
 					showTypeInStruct = t.Elem().Kind() == reflect.Interface
 				}
-				pp.printValue(mv, showTypeInStruct, true)
+				pp.at(step{key: k}).printValue(mv, showTypeInStruct, true)
 				if expand {
-					if humanize {
-						if newlineNeeded() {
-							writeString(pp, "\n")
+					if p.c.Humanize {
+						if p.newlineNeeded() {
+							pp.writeString("\n")
 						}
 					} else {
-						writeString(pp, ",\n")
+						pp.writeString(",\n")
 					}
 				} else if i < v.Len()-1 {
-					writeString(pp, ", ")
+					pp.writeString(", ")
 				}
 			}
+			if more := v.Len() - len(keys); more > 0 {
+				pp.printMore(more, "entry")
+				pp.endMore(expand)
+			}
 			if expand {
 				pp.tw.Flush()
 			}
 		}
 		// '{' to balance below line
-		writeByte(p, '}')
+		p.writeByte('}')
 	case reflect.Struct:
 		t := v.Type()
-		if v.CanAddr() {
-			addr := v.UnsafeAddr()
-			vis := visit{addr, t}
-			if vd, ok := p.visited[vis]; ok && vd < p.depth {
-				p.fmtString(t.String()+"{(CYCLIC REFERENCE)}", false)
-				break // don't print v again
-			}
-			p.visited[vis] = p.depth
-		}
-
 		if showType {
-			if !humanize {
-				writeString(p, t.String())
+			if !p.c.Humanize {
+				p.writeString(p.typeString(t))
 			}
 		}
-		writeByte(p, '{') // '}' to balance the char
-		if nonzero(v) || humanize {
-			expand = !canInline(v.Type())
+		p.writeByte('{') // '}' to balance the char
+		if nonzero(v) || p.c.Humanize {
+			expand = !p.canInline(v.Type())
 			pp := p
 			if expand {
-				if indentNeeded() {
-					writeByte(p, '\n')
+				if p.indentNeeded() {
+					p.writeByte('\n')
 					pp = p.indent()
 				}
 			}
+			pp = pp.deeper()
 			for i := 0; i < v.NumField(); i++ {
+				if p.lit != nil && !pp.at(step{field: t.Field(i).Name}).settable(t, i, getField(v, i)) {
+					continue
+				}
 				showTypeInStruct := true
-				if humanize {
+				if p.c.Humanize {
 					showTypeInStruct = false
 				}
 				if f := t.Field(i); f.Name != "" {
 					name := f.Name
 					omitEmpty := false
-					if humanize {
+					if p.c.Humanize {
 						tag := f.Tag.Get("pretty")
 						if tag == "-" {
 							continue
@@ -441,24 +590,24 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 							continue
 						}
 					}
-					writeString(pp, name)
-					writeByte(pp, ':')
+					pp.writeString(name)
+					pp.writeByte(':')
 					if expand {
-						writeByte(pp, '\t')
+						pp.writeByte('\t')
 					}
-					if !humanize {
-						showTypeInStruct = labelType(f.Type)
+					if !p.c.Humanize {
+						showTypeInStruct = labelType(f.Type) || p.lit != nil && canExpand(f.Type)
 					}
 				}
-				pp.printValue(getField(v, i), showTypeInStruct, true)
-				if humanize {
-					if newlineNeeded() {
-						writeByte(pp, '\n')
+				pp.at(step{field: t.Field(i).Name}).printValue(getField(v, i), showTypeInStruct, true)
+				if p.c.Humanize {
+					if p.newlineNeeded() {
+						pp.writeByte('\n')
 					}
 				} else if expand {
-					writeString(pp, ",\n")
+					pp.writeString(",\n")
 				} else if i < v.NumField()-1 {
-					writeString(pp, ", ")
+					pp.writeString(", ")
 				}
 			}
 			if expand {
@@ -466,98 +615,257 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 			}
 		}
 		// '{' to balance below line
-		writeByte(p, '}')
+		p.writeByte('}')
 	case reflect.Interface:
 		switch e := v.Elem(); {
 		case e.Kind() == reflect.Invalid:
-			writeString(p, "nil")
+			p.writeString("nil")
 		case e.IsValid():
-			pp := *p
-			pp.depth++
-			pp.printValue(e, showType, true)
+			p.printValue(e, showType, true)
 		default:
-			writeString(p, v.Type().String())
-			writeString(p, "(nil)")
+			p.writeString(p.typeString(v.Type()))
+			p.writeString("(nil)")
 		}
 	case reflect.Array, reflect.Slice:
 		t := v.Type()
 		if showType {
-			writeString(p, t.String())
+			p.writeString(p.typeString(t))
 		}
 		if v.Kind() == reflect.Slice && v.IsNil() && showType {
-			writeString(p, "(nil)")
+			p.writeString("(nil)")
 			break
 		}
 		if v.Kind() == reflect.Slice && v.IsNil() {
-			writeString(p, "nil")
+			p.writeString("nil")
 			break
 		}
-		writeByte(p, '{') // '}' to balance the char
-		expand = !canInline(v.Type())
+		p.writeByte('{') // '}' to balance the char
+		expand = !p.canInline(v.Type())
 		pp := p
 		if expand {
-			if indentNeeded() {
-				writeByte(p, '\n')
+			if p.indentNeeded() {
+				p.writeByte('\n')
 				pp = p.indent()
 			}
 		}
-		for i := 0; i < v.Len(); i++ {
+		pp = pp.deeper()
+		n := v.Len()
+		if max := p.c.MaxElements; max > 0 && n > max {
+			n = max
+		}
+		for i := 0; i < n; i++ {
 			showTypeInSlice := t.Elem().Kind() == reflect.Interface
-			pp.printValue(v.Index(i), showTypeInSlice, true)
-			if humanize {
-				if newlineNeeded() {
-					writeByte(pp, '\n')
+			pp.at(step{index: i}).printValue(v.Index(i), showTypeInSlice, true)
+			if p.c.Humanize {
+				if p.newlineNeeded() {
+					pp.writeByte('\n')
 				}
 			} else if expand {
-				writeString(pp, ",\n")
+				pp.writeString(",\n")
 			} else if i < v.Len()-1 {
-				writeString(pp, ", ")
+				pp.writeString(", ")
 			}
 		}
+		if more := v.Len() - n; more > 0 {
+			pp.printMore(more, "element")
+			pp.endMore(expand)
+		}
 		if expand {
 			pp.tw.Flush()
 		}
 		// '{' to balance below line
-		writeByte(p, '}')
+		p.writeByte('}')
 	case reflect.Ptr:
 		e := v.Elem()
 		if !e.IsValid() {
-			if humanize {
-				writeString(p, "nil")
+			if p.c.Humanize {
+				p.writeString("nil")
 			} else {
-				writeByte(p, '(')
-				writeString(p, v.Type().String())
-				writeString(p, ")(nil)")
+				p.writeByte('(')
+				p.writeString(p.typeString(v.Type()))
+				p.writeString(")(nil)")
 			}
+		} else if p.lit != nil {
+			p.printPointerLiteral(v)
 		} else {
-			pp := *p
-			pp.depth++
-			if !humanize {
-				writeByte(pp, '&')
+			if !p.c.Humanize {
+				p.writeByte('&')
 			}
-			pp.printValue(e, true, true)
+			p.printValue(e, true, true)
 		}
 	case reflect.Chan:
+		if p.lit != nil {
+			p.printNilLiteral(v, showType)
+			break
+		}
 		x := v.Pointer()
 		if showType {
-			writeByte(p, '(')
-			writeString(p, v.Type().String())
+			p.writeByte('(')
+			p.writeString(v.Type().String())
 			fmt.Fprintf(p, ")(%#v)", x)
 		} else {
 			fmt.Fprintf(p, "%#v", x)
 		}
 	case reflect.Func:
-		writeString(p, v.Type().String())
-		writeString(p, " {...}")
+		if p.lit != nil {
+			p.printNilLiteral(v, showType)
+			break
+		}
+		p.writeString(v.Type().String())
+		p.writeString(" {...}")
 	case reflect.UnsafePointer:
+		if p.lit != nil {
+			p.printNilLiteral(v, showType)
+			break
+		}
 		p.printInline(v, v.Pointer(), showType)
 	case reflect.Invalid:
-		writeString(p, "nil")
+		p.writeString("nil")
+	}
+}
+
+// printMethod prints v using its own GoString method (Go syntax output)
+// or its Error or String method (humanized output) when CallMethods is
+// set, returning false if it printed nothing.  Nil pointers are never
+// handed to these methods and a panicking method is reported inline the
+// way package fmt does it.
+func (p *printer) printMethod(v reflect.Value) (handled bool) {
+	if !p.c.CallMethods || !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		return false // the dynamic value gets its turn
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		if v.IsNil() {
+			return false
+		}
+	}
+	x, ok := interfaceOf(v)
+	if !ok {
+		return false
+	}
+	var name string
+	var method func() string
+	if p.c.Humanize {
+		switch m := x.(type) {
+		case error:
+			name, method = "Error", m.Error
+		case fmt.Stringer:
+			name, method = "String", m.String
+		}
+	} else if m, ok := x.(fmt.GoStringer); ok {
+		name, method = "GoString", m.GoString
+	}
+	if method == nil {
+		return false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.writeString(fmt.Sprintf("%%!v(PANIC=%s method: %v)", name, r))
+			handled = true
+		}
+	}()
+	p.writeString(method())
+	return true
+}
+
+// interfaceOf returns the value held by v as an interface{}, reading
+// through unexported struct fields when v is addressable.  It returns
+// false if v's value can't be had.
+func interfaceOf(v reflect.Value) (interface{}, bool) {
+	if v.CanInterface() {
+		return v.Interface(), true
+	}
+	if v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem().Interface(), true
+	}
+	return nil, false
+}
+
+// readable returns v, or if v was reached through an unexported struct
+// field and is addressable, a Value of the same variable that can be read
+// with Interface, as can the values reached through it.
+func readable(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// printElided is used once the maximum depth has been reached, instead
+// of the contents of a non-empty map, struct, array or slice it prints a
+// short summary of its shape, like "pkg.Type{…3 fields}" or "[]T{…120
+// items}" (humanized output drops the type and brackets).  It returns
+// false, printing nothing, if v has no contents worth eliding.
+func (p *printer) printElided(v reflect.Value, showType bool) bool {
+	var n int
+	var what string
+	switch v.Kind() {
+	case reflect.Struct:
+		n, what = v.NumField(), "field"
+	case reflect.Map:
+		n, what = v.Len(), "entry"
+	case reflect.Array, reflect.Slice:
+		n, what = v.Len(), "item"
+	}
+	if n == 0 {
+		return false
+	}
+	p.problem("elided by MaxDepth")
+	summary := fmt.Sprintf("…%d %s", n, plural(n, what))
+	if p.c.Humanize {
+		p.writeString(summary)
+		return true
+	}
+	if showType {
+		p.writeString(v.Type().String())
+	}
+	p.writeString("{" + summary + "}")
+	return true
+}
+
+// printMore writes the marker standing in for the n bytes, elements or
+// entries of a string, array, slice or map that were cut off by one of
+// the truncation limits (eg: "... (4980 more elements)").
+func (p *printer) printMore(n int, what string) {
+	p.problem("truncated, %d more %s", n, plural(n, what))
+	p.writeString(fmt.Sprintf("... (%d more %s)", n, plural(n, what)))
+}
+
+// endMore finishes the line holding a collection's printMore marker the
+// same way the collection's items are finished.
+func (p *printer) endMore(expand bool) {
+	if p.c.Humanize {
+		if p.newlineNeeded() {
+			p.writeByte('\n')
+		}
+	} else if expand {
+		p.writeByte('\n')
+	}
+}
+
+// truncate cuts s down to at most max bytes without splitting a rune.
+func truncate(s string, max int) string {
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// plural returns the plural form of noun unless n is 1.
+func plural(n int, noun string) string {
+	switch {
+	case n == 1:
+		return noun
+	case strings.HasSuffix(noun, "y"):
+		return noun[:len(noun)-1] + "ies"
 	}
+	return noun + "s"
 }
 
-func canInline(t reflect.Type) bool {
-	if humanize {
+func (p *printer) canInline(t reflect.Type) bool {
+	if p.c.Humanize {
 		return false
 	}
 	switch t.Kind() {
@@ -601,44 +909,44 @@ func labelType(t reflect.Type) bool {
 }
 
 func (p *printer) fmtString(s string, quote bool) {
-	if quote || (humanize && s != "" && strings.TrimSpace(s) == "") {
+	if quote || (p.c.Humanize && s != "" && strings.TrimSpace(s) == "") {
 		s = strconv.Quote(s)
 	}
-	writeString(p, s)
+	p.writeString(s)
 }
 
-func writeByte(w io.Writer, b byte) {
+func (p *printer) writeByte(b byte) {
 	// if "humanized" output don't print struct/array format chars '{' and '}'
 	// which are currently always done via writeByte only, sweet
-	if humanize && (b == '{' || b == '}') {
+	if p.c.Humanize && (b == '{' || b == '}') {
 		// '{' to balance below line, fixes dumb editor bracket matching
 		if b == '}' {
-			sawCloseBracketLast++
+			p.line.sawCloseBracketLast++
 		}
 		return
 	}
-	if humanize {
+	if p.c.Humanize {
 		if b == '\n' {
-			currOutputLine = ""
+			p.line.currOutputLine = ""
 		} else {
-			currOutputLine = currOutputLine + string(b)
+			p.line.currOutputLine = p.line.currOutputLine + string(b)
 		}
 	}
-	sawCloseBracketLast = 0
-	w.Write([]byte{b})
+	p.line.sawCloseBracketLast = 0
+	p.Write([]byte{b})
 }
 
-func writeString(w io.Writer, s string) {
-	if humanize {
+func (p *printer) writeString(s string) {
+	if p.c.Humanize {
 		// all close brackets (for fmt'ing) use writeByte() so zero it out
 		if s != "" {
-			sawCloseBracketLast = 0
+			p.line.sawCloseBracketLast = 0
 		}
 		// in case multi-line string, split it on newline, store curr last line
 		lines := strings.Split(s, "\n")
-		currOutputLine = lines[len(lines)-1]
+		p.line.currOutputLine = lines[len(lines)-1]
 	}
-	io.WriteString(w, s)
+	io.WriteString(p, s)
 }
 
 func getField(v reflect.Value, i int) reflect.Value {
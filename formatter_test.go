@@ -3,7 +3,9 @@ package pretty
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"unsafe"
 )
@@ -75,6 +77,8 @@ var gosyntax = []test{
 	{unsafe.Pointer(uintptr(unsafe.Pointer(&long))), fmt.Sprintf("unsafe.Pointer(0x%02x)", uintptr(unsafe.Pointer(&long)))},
 	{func(int) {}, "func(int) {...}"},
 	{map[int]int{1: 1}, "map[int]int{1:1}"},
+	{map[int]int{3: 3, 1: 1, 2: 2}, "map[int]int{1:1, 2:2, 3:3}"},
+	{map[string]bool{"b": true, "a": false, "c": true}, `map[string]bool{"a":false, "b":true, "c":true}`},
 	{int32(1), "int32(1)"},
 	{io.EOF, `&errors.errorString{s:"EOF"}`},
 	{[]string{"a"}, `[]string{"a"}`},
@@ -188,6 +192,7 @@ var humanizesyntax = []test{
 	{unsafe.Pointer(uintptr(unsafe.Pointer(&long))), fmt.Sprintf("0x%02x", uintptr(unsafe.Pointer(&long)))},
 	{func(int) {}, "func(int) {...}"},
 	{map[int]int{1: 1}, "1: 1\n"}, // CONSIDER: is \n needed here?
+	{map[string]int{"b": 2, "c": 3, "a": 1}, "a: 1\nb: 2\nc: 3\n"},
 	{int32(1), "1"},
 	{io.EOF, "s: EOF\n"},   // CONSIDER: is \n needed here?
 	{[]string{"a"}, "a\n"}, // CONSIDER: is \n needed here?
@@ -267,10 +272,11 @@ otherLongFieldName: nil
 
 func TestHumanizeSyntax(t *testing.T) {
 	// test indentation setting and "humanized" output format
-	SetOutputIndentLevel(2)
-	SetHumanize(true)
+	c := NewConfig()
+	c.OutputIndentLevel = 2
+	c.Humanize = true
 	for _, tt := range humanizesyntax {
-		s := fmt.Sprintf("%# v", Formatter(tt.v))
+		s := fmt.Sprintf("%# v", c.Formatter(tt.v))
 		if tt.s != s {
 			t.Errorf("expected %q", tt.s)
 			t.Errorf("got      %q", s)
@@ -278,9 +284,270 @@ func TestHumanizeSyntax(t *testing.T) {
 			t.Errorf("gotraw\n%s", s)
 		}
 	}
-	// reset to usual values:
-	SetOutputIndentLevel(4)
-	SetHumanize(false)
+}
+
+func TestConfigIndependent(t *testing.T) {
+	c := NewConfig()
+	c.OutputIndentLevel = 2
+	c.Humanize = true
+	v := T{3, 4}
+	if got, want := c.Sprint(v), "x: 3\ny: 4\n"; got != want {
+		t.Errorf("Config.Sprint = %q want %q", got, want)
+	}
+	if got, want := Sprint(v), "pretty.T{x:3, y:4}"; got != want {
+		t.Errorf("Sprint = %q want %q", got, want)
+	}
+	if Humanize() {
+		t.Errorf("Humanize() = true after changing a separate Config")
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	type Deep struct {
+		S  SA
+		L  []int
+		M  map[string]int
+		E  []int
+		SS []T
+	}
+	v := Deep{
+		S:  SA{&T{1, 2}, T{3, 4}},
+		L:  make([]int, 120),
+		M:  map[string]int{"a": 1},
+		E:  []int{},
+		SS: []T{{1, 2}},
+	}
+	c := NewConfig()
+	c.MaxDepth = 1
+	want := `pretty.Deep{
+    S:  pretty.SA{…2 fields},
+    L:  {…120 items},
+    M:  {…1 entry},
+    E:  {},
+    SS: {…1 item},
+}`
+	if s := fmt.Sprintf("%# v", c.Formatter(v)); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+
+	want = "[]interface {}{\n    []int{…3 items},\n}"
+	if s := fmt.Sprintf("%# v", c.Formatter([]interface{}{[]int{1, 2, 3}})); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+
+	c.MaxDepth = 2
+	want = `pretty.Deep{
+    S:  pretty.SA{
+        t:  &pretty.T{…2 fields},
+        v:  pretty.T{…2 fields},
+    },`
+	if s := fmt.Sprintf("%# v", c.Formatter(v)); !strings.HasPrefix(s, want) {
+		t.Errorf("expected prefix %q", want)
+		t.Errorf("got             %q", s)
+	}
+
+	c.MaxDepth = 1
+	c.Humanize = true
+	c.OutputIndentLevel = 2
+	want = "t: …2 fields\nv: …2 fields\n"
+	if s := fmt.Sprintf("%# v", c.Formatter(SA{&T{1, 2}, T{3, 4}})); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+
+	c = NewConfig()
+	c.MaxDepth = 0
+	r := &R{i: 1}
+	for i := 2; i <= 20; i++ {
+		r = &R{i: i, R: r}
+	}
+	if s := fmt.Sprintf("%# v", c.Formatter(r)); strings.Contains(s, "…") {
+		t.Errorf("unlimited depth elided output:\n%s", s)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	c := NewConfig()
+	c.MaxStringLen = 5
+	c.MaxElements = 3
+	c.MaxMapEntries = 2
+	tests := []test{
+		{"abc", `"abc"`},
+		{long, `"abcde"... (57 more bytes)`},
+		{"héllo wörld", `"héll"... (8 more bytes)`},
+		{[]int{1, 2, 3}, "[]int{1, 2, 3}"},
+		{make([]int, 5000), "[]int{0, 0, 0, ... (4997 more elements)}"},
+		{[5]int{1, 2, 3, 4, 5}, "[5]int{1, 2, 3, ... (2 more elements)}"},
+		{map[int]int{1: 1, 2: 2, 3: 3, 4: 4}, "map[int]int{1:1, 2:2, ... (2 more entries)}"},
+		{
+			[]T{{1, 2}, {3, 4}, {5, 6}, {7, 8}},
+			`[]pretty.T{
+    {x:1, y:2},
+    {x:3, y:4},
+    {x:5, y:6},
+    ... (1 more element)
+}`,
+		},
+		{
+			map[int]T{1: {}, 2: {}, 3: {}},
+			`map[int]pretty.T{
+    1:  {},
+    2:  {},
+    ... (1 more entry)
+}`,
+		},
+	}
+	for _, tt := range tests {
+		s := fmt.Sprintf("%# v", c.Formatter(tt.v))
+		if tt.s != s {
+			t.Errorf("expected %q", tt.s)
+			t.Errorf("got      %q", s)
+		}
+	}
+
+	c.Humanize = true
+	c.OutputIndentLevel = 2
+	want := "1\n2\n3\n... (2 more elements)\n"
+	if s := fmt.Sprintf("%# v", c.Formatter([]int{1, 2, 3, 4, 5})); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+	want = "a: 1\nb: 2\n... (1 more entry)\n"
+	if s := fmt.Sprintf("%# v", c.Formatter(map[string]int{"a": 1, "b": 2, "c": 3})); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+	want = "abcde... (57 more bytes)"
+	if s := fmt.Sprintf("%# v", c.Formatter(long)); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+}
+
+type GoStr struct{ n int }
+
+func (g GoStr) GoString() string { return fmt.Sprintf("pretty.NewGoStr(%d)", g.n) }
+
+type Panicky struct{ p *int }
+
+func (p Panicky) String() string { return fmt.Sprint(*p.p) }
+
+func (p Panicky) GoString() string { return fmt.Sprint(*p.p) }
+
+type Methods struct {
+	E   error
+	s   *Stringer
+	ns  *Stringer
+	g   GoStr
+	pan Panicky
+}
+
+func TestCallMethods(t *testing.T) {
+	v := Methods{E: io.EOF, s: &Stringer{}, g: GoStr{7}}
+	c := NewConfig()
+	c.CallMethods = true
+	want := `pretty.Methods{
+    E:   &errors.errorString{s:"EOF"},
+    s:   &pretty.Stringer{},
+    ns:  (*pretty.Stringer)(nil),
+    g:   pretty.NewGoStr(7),
+    pan: %!v(PANIC=GoString method: runtime error: invalid memory address or nil pointer dereference),
+}`
+	if s := fmt.Sprintf("%# v", c.Formatter(v)); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+		t.Errorf("gotraw\n%s", s)
+	}
+
+	c.Humanize = true
+	c.OutputIndentLevel = 2
+	want = `E:  EOF
+s:  foo
+ns: nil
+g:  
+  n: 7
+pan: %!v(PANIC=String method: runtime error: invalid memory address or nil pointer dereference)
+`
+	if s := fmt.Sprintf("%# v", c.Formatter(v)); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+		t.Errorf("gotraw\n%s", s)
+	}
+}
+
+func TestAddressable(t *testing.T) {
+	type inner struct{ n int }
+	for _, tt := range []struct {
+		v    interface{}
+		copy bool
+	}{
+		{struct{ N int }{1}, false},
+		{struct{ P *inner }{}, false},
+		{[]inner{{1}}, false},
+		{GoStr{1}, true},
+		{[2]struct{ G GoStr }{}, true},
+	} {
+		v := reflect.ValueOf(tt.v)
+		if got := addressable(v).CanAddr(); got != tt.copy {
+			t.Errorf("addressable(%T) copied = %v want %v", tt.v, got, tt.copy)
+		}
+	}
+}
+
+// TestConcurrent renders the humanize and gosyntax tables from many
+// goroutines at once, run it with -race to catch shared render state.
+func TestConcurrent(t *testing.T) {
+	hc := NewConfig()
+	hc.OutputIndentLevel = 2
+	hc.Humanize = true
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			for _, tt := range humanizesyntax {
+				if s := fmt.Sprintf("%# v", hc.Formatter(tt.v)); s != tt.s {
+					t.Errorf("humanize: expected %q got %q", tt.s, s)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, tt := range gosyntax {
+				if s := fmt.Sprintf("%# v", Formatter(tt.v)); s != tt.s {
+					t.Errorf("gosyntax: expected %q got %q", tt.s, s)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, tt := range humanizesyntax {
+				if s := hc.Sprint(tt.v); s != tt.s {
+					t.Errorf("humanize Sprint: expected %q got %q", tt.s, s)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, tt := range gosyntax {
+				want := tt.s
+				if str, ok := tt.v.(string); ok {
+					want = str // Sprint leaves top-level strings unquoted
+				}
+				if s := Sprint(tt.v); s != want {
+					t.Errorf("gosyntax Sprint: expected %q got %q", want, s)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type R struct {
+	i int
+	*R
 }
 
 type I struct {
@@ -304,10 +571,6 @@ func TestCycle(t *testing.T) {
 		t.Errorf("Repeated address detected as cyclic reference:\n%s", s)
 	}
 
-	type R struct {
-		i int
-		*R
-	}
 	r := &R{
 		i: 1,
 		R: &R{
@@ -394,3 +657,92 @@ func TestCycle(t *testing.T) {
 	*iv = *i
 	t.Logf("Example long interface cycle:\n%# v", Formatter(i))
 }
+
+func TestCycleMarker(t *testing.T) {
+	r := &R{i: 1, R: &R{i: 2}}
+	r.R.R = r
+	var x interface{}
+	x = &x
+	m := map[string]interface{}{"k": 1}
+	m["self"] = m
+	s := []interface{}{1, nil}
+	s[1] = s
+	for _, tt := range []struct {
+		v interface{}
+		s string
+	}{
+		{r, "&pretty.R{\n    i:  1,\n    R:  &pretty.R{\n        i:  2,\n        R:  (CYCLIC REFERENCE to .),\n    },\n}"},
+		{x, "&(CYCLIC REFERENCE to .)"},
+		{m, "map[string]interface {}{\n    \"k\":    int(1),\n    \"self\": (CYCLIC REFERENCE to .),\n}"},
+		{struct{ S []interface{} }{s}, "struct { S []interface {} }{\n    S:  {\n        int(1),\n        (CYCLIC REFERENCE to .S),\n    },\n}"},
+	} {
+		c := NewConfig()
+		c.MaxDepth = 0
+		if s := fmt.Sprintf("%# v", c.Formatter(tt.v)); s != tt.s {
+			t.Errorf("cycle:\n%s\nwant:\n%s", s, tt.s)
+		}
+	}
+}
+
+func TestLabelShared(t *testing.T) {
+	type A struct{ *A }
+	p := &A{}
+	cyc := &A{}
+	cyc.A = cyc
+	m := map[string]int{"a": 1}
+	s := []int{1, 2}
+	for _, tt := range []struct {
+		v interface{}
+		s string
+	}{
+		{[]*A{p, p}, "[]*pretty.A{\n    #1=&pretty.A{},\n    #1,\n}"},
+		{cyc, "#1=&pretty.A{\n    A:  #1,\n}"},
+		{
+			struct {
+				M, N    map[string]int
+				S, U, W []int
+			}{m, m, s, s, s[:1]},
+			`struct { M map[string]int; N map[string]int; S []int; U []int; W []int }{
+    M:  #1={"a":1},
+    N:  #1,
+    S:  #2={1, 2},
+    U:  #2,
+    W:  {1},
+}`,
+		},
+		{[]*int{new(int), new(int)}, "[]*int{\n    &int(0),\n    &int(0),\n}"},
+	} {
+		c := NewConfig()
+		c.LabelShared = true
+		if s := fmt.Sprintf("%# v", c.Formatter(tt.v)); s != tt.s {
+			t.Errorf("labelled:\n%s\nwant:\n%s", s, tt.s)
+		}
+	}
+}
+
+// TestLabelSharedLimits checks that values elided or cut off by the
+// output limits don't get labels nothing refers to.
+func TestLabelSharedLimits(t *testing.T) {
+	type A struct{ X int }
+	type B struct{ P, Q *A }
+	a := &A{1}
+	for _, tt := range []struct {
+		v     interface{}
+		depth int
+		elems int
+		s     string
+	}{
+		{[]*A{a, a}, 0, 1, "[]*pretty.A{\n    &pretty.A{X:1},\n    ... (1 more element)\n}"},
+		{[]*A{a, a, a}, 0, 2, "[]*pretty.A{\n    #1=&pretty.A{X:1},\n    #1,\n    ... (1 more element)\n}"},
+		{B{a, a}, 1, 0, "pretty.B{\n    P:  &pretty.A{…1 field},\n    Q:  &pretty.A{…1 field},\n}"},
+		{[]interface{}{a, B{Q: a}}, 2, 0, "[]interface {}{\n    &pretty.A{X:1},\n    pretty.B{\n        P:  (*pretty.A)(nil),\n        Q:  &pretty.A{…1 field},\n    },\n}"},
+	} {
+		c := NewConfig()
+		c.LabelShared = true
+		c.MaxDepth = tt.depth
+		c.MaxElements = tt.elems
+		if s := fmt.Sprintf("%# v", c.Formatter(tt.v)); s != tt.s {
+			t.Errorf("labelled:\n%s\nwant:\n%s", s, tt.s)
+		}
+	}
+}
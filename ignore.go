@@ -0,0 +1,107 @@
+package pretty
+
+import (
+	"reflect"
+)
+
+// ignoreField reports whether Diff skips struct field f wherever it is
+// found (see Config.IgnoreFields).
+func (c *Config) ignoreField(f reflect.StructField) bool {
+	if c.IgnoreUnexported && f.PkgPath != "" {
+		return true
+	}
+	if name, opts := parseTag(f.Tag.Get("pretty")); name == "-" || opts.Contains("nodiff") {
+		return true
+	}
+	for _, name := range c.IgnoreFields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ignorePath reports whether Diff skips the values at the current path
+// (see Config.IgnorePaths).
+func (w differ) ignorePath() bool {
+	for _, pattern := range w.ignore {
+		if pattern.match(w.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// A pathPattern is one of Config.IgnorePaths split into the segments a
+// Path is written with (see PathStep.segment).
+type pathPattern []string
+
+// ignorePatterns returns the parsed Config.IgnorePaths.
+func (c *Config) ignorePatterns() []pathPattern {
+	var patterns []pathPattern
+	for _, s := range c.IgnorePaths {
+		patterns = append(patterns, patternSegments(s))
+	}
+	return patterns
+}
+
+// patternSegments splits a path pattern into the segments a Path is
+// written with, a leading "." is optional.
+func patternSegments(pattern string) []string {
+	var segs []string
+	for i := 0; i < len(pattern); {
+		switch pattern[i] {
+		case '.':
+			i++
+		case '[':
+			// find the closing bracket, skipping over quoted keys
+			j, quote := i+1, byte(0)
+			for ; j < len(pattern); j++ {
+				c := pattern[j]
+				switch {
+				case quote != 0 && c == '\\':
+					j++
+				case quote != 0 && c == quote:
+					quote = 0
+				case quote == 0 && (c == '"' || c == '\''):
+					quote = c
+				case quote == 0 && c == ']':
+					j++
+					goto done
+				}
+			}
+		done:
+			segs = append(segs, pattern[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(pattern) && pattern[j] != '.' && pattern[j] != '[' {
+				j++
+			}
+			segs = append(segs, pattern[i:j])
+			i = j
+		}
+	}
+	return segs
+}
+
+// match reports whether path p matches the pattern, where "[*]" matches
+// any index or key and "*" any field name.
+func (pattern pathPattern) match(p Path) bool {
+	if len(pattern) != len(p) {
+		return false
+	}
+	for i, seg := range pattern {
+		s := p[i]
+		switch {
+		case s.Field != "":
+			if seg != "*" && seg != s.Field {
+				return false
+			}
+		case seg == "[*]":
+		case seg == "" || seg[0] != '[' || seg != s.segment():
+			return false
+		}
+	}
+	return true
+}
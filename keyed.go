@@ -0,0 +1,155 @@
+package pretty
+
+import (
+	"reflect"
+)
+
+// keyField is the field the elements of a slice are matched up by (see
+// Config.KeyFields).
+type keyField struct {
+	index int
+	name  string // what labels it in a Path
+	ptr   bool   // the elements are pointers to structs
+}
+
+// keyField returns the field that elements of type t are matched up by,
+// or false if they are matched up by position.
+func (c *Config) keyField(t reflect.Type) (keyField, bool) {
+	var kf keyField
+	if t.Kind() == reflect.Ptr {
+		t, kf.ptr = t.Elem(), true
+	}
+	if t.Kind() != reflect.Struct {
+		return keyField{}, false
+	}
+	name, named := c.KeyFields[t]
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, opts := parseTag(f.Tag.Get("pretty"))
+		if named && f.Name != name || !named && !opts.Contains("key") {
+			continue
+		}
+		if !f.Type.Comparable() {
+			return keyField{}, false
+		}
+		kf.index, kf.name = i, f.Name
+		if !named && isValidTag(tag) {
+			kf.name = tag
+		}
+		return kf, true
+	}
+	return keyField{}, false
+}
+
+// key returns the key of v, an element matched up by kf, or false if it
+// has none: v is a nil pointer or its key holds a value that can't be a
+// map key (eg: a slice in an interface).
+func (kf keyField) key(v reflect.Value) (reflect.Value, bool) {
+	if kf.ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	k := v.Field(kf.index)
+	return k, hashable(k)
+}
+
+// hashable reports whether v, of a comparable type, holds only values
+// that can be map keys.
+func hashable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || hashable(v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !hashable(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !hashable(v.Field(i)) {
+				return false
+			}
+		}
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	}
+	return true
+}
+
+// diffKeyed compares the elements of av and bv, arrays or slices of the
+// same type, matching them up by the key field kf: elements with the same
+// key are compared, the others reported as Removed or Added.  If any
+// element has no key they are compared as usual instead.
+func (w differ) diffKeyed(av, bv reflect.Value, kf keyField) {
+	keys := func(v reflect.Value) ([]reflect.Value, bool) {
+		ks := make([]reflect.Value, v.Len())
+		for i := range ks {
+			k, ok := kf.key(v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			ks[i] = k
+		}
+		return ks, true
+	}
+	// keys from unexported fields can only be hashed if readable
+	ak, aok := keys(readable(av))
+	bk, bok := keys(readable(bv))
+	if !aok || !bok {
+		w.diffElems(av, bv)
+		return
+	}
+	step := func(i int, k reflect.Value) differ {
+		return w.at(PathStep{Index: i, Key: k, KeyName: kf.name})
+	}
+	match := matchKeys(ak, bk)
+	matched := make([]bool, len(bk))
+	for i, j := range match {
+		if j < 0 {
+			step(i, ak[i]).change(Removed, av.Index(i), reflect.Value{})
+			continue
+		}
+		matched[j] = true
+		step(i, ak[i]).diff(av.Index(i), bv.Index(j))
+	}
+	for j, k := range bk {
+		if !matched[j] {
+			step(j, k).change(Added, reflect.Value{}, bv.Index(j))
+		}
+	}
+}
+
+// matchKeys returns, for each of the keys ak, the index of the first key
+// equal to it in bk not matched to an earlier one, or -1 if there is none.
+// Like keyDiff it looks keys up in a Go map, falling back on comparing
+// every pair with keyEqual when a key can't be had as an interface{}.
+func matchKeys(ak, bk []reflect.Value) []int {
+	match := make([]int, len(ak))
+	if canInterface(ak) && canInterface(bk) {
+		index := make(map[interface{}][]int, len(bk))
+		for j, k := range bk {
+			index[k.Interface()] = append(index[k.Interface()], j)
+		}
+		for i, k := range ak {
+			match[i] = -1
+			if js := index[k.Interface()]; len(js) > 0 {
+				match[i], index[k.Interface()] = js[0], js[1:]
+			}
+		}
+		return match
+	}
+	matched := make([]bool, len(bk))
+	for i, k := range ak {
+		match[i] = -1
+		for j := range bk {
+			if !matched[j] && keyEqual(k, bk[j]) {
+				match[i], matched[j] = j, true
+				break
+			}
+		}
+	}
+	return match
+}
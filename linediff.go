@@ -0,0 +1,188 @@
+package pretty
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// diffStrings reports whether Diff describes how strings a and b differ
+// rather than quoting them (see Config.DiffStrings).
+func (c *Config) diffStrings(a, b string) bool {
+	if !c.DiffStrings {
+		return false
+	}
+	if strings.Contains(a, "\n") || strings.Contains(b, "\n") {
+		return true
+	}
+	return len(a) >= c.DiffStringsMinLen || len(b) >= c.DiffStringsMinLen
+}
+
+// stringDiff describes how strings a and b differ (see Config.DiffStrings):
+// multi-line strings by a unified diff of their lines, others quoted, cut
+// down around their first difference if long, along with where that is.
+func (c *Config) stringDiff(a, b string) string {
+	if strings.Contains(a, "\n") || strings.Contains(b, "\n") {
+		return c.lineDiff(a, b)
+	}
+	ra, rb := []rune(a), []rune(b)
+	off := 0
+	for off < len(ra) && off < len(rb) && ra[off] == rb[off] {
+		off++
+	}
+	return fmt.Sprintf("%s != %s (first difference at rune %d)", snippet(ra, off), snippet(rb, off), off)
+}
+
+// snippetRunes is how many runes before, and after, the first difference
+// are shown of a long single-line string.
+const snippetRunes = 16
+
+// snippet quotes the runes of a string around offset off, with "..."
+// marking the runes left out.
+func snippet(r []rune, off int) string {
+	start, end := off-snippetRunes, off+snippetRunes
+	var prefix, suffix string
+	if start > 0 {
+		prefix = "..."
+	} else {
+		start = 0
+	}
+	if end < len(r) {
+		suffix = "..."
+	} else {
+		end = len(r)
+	}
+	return prefix + strconv.Quote(string(r[start:end])) + suffix
+}
+
+// lineDiff returns a unified diff of the lines of a and b.
+func (c *Config) lineDiff(a, b string) string {
+	al, bl := splitLines(a), splitLines(b)
+	edits := c.alignLines(len(al), len(bl), func(i, j int) bool {
+		return al[i] == bl[j]
+	})
+	return c.unified(al, bl, edits)
+}
+
+// alignLines aligns n lines with m (see align), given eq to tell whether
+// line i of the first equals line j of the second.  The common first and
+// last lines are skipped cheaply, if what is left between them is too
+// big to align (see Config.MaxDiffAlign) all of it is replaced.
+func (c *Config) alignLines(n, m int, eq func(i, j int) bool) []edit {
+	pre := 0
+	for pre < n && pre < m && eq(pre, pre) {
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && eq(n-1-suf, m-1-suf) {
+		suf++
+	}
+	var edits []edit
+	for i := 0; i < pre; i++ {
+		edits = append(edits, edit{'=', i, i})
+	}
+	dn, dm := n-pre-suf, m-pre-suf
+	if max := c.MaxDiffAlign; max > 0 && dn*dm > max {
+		for i := 0; i < dn; i++ {
+			edits = append(edits, edit{'-', pre + i, pre})
+		}
+		for j := 0; j < dm; j++ {
+			edits = append(edits, edit{'+', pre + dn, pre + j})
+		}
+	} else {
+		for _, e := range align(dn, dm, func(i, j int) bool { return eq(pre+i, pre+j) }) {
+			edits = append(edits, edit{e.op, pre + e.i, pre + e.j})
+		}
+	}
+	for k := 0; k < suf; k++ {
+		edits = append(edits, edit{'=', n - suf + k, m - suf + k})
+	}
+	return edits
+}
+
+// unified returns the edits to lines al and bl as a unified diff, each
+// hunk of changed lines has up to c.DiffContext unchanged lines around it.
+func (c *Config) unified(al, bl []string, edits []edit) string {
+	ctx := c.DiffContext
+	if ctx < 0 {
+		ctx = 0
+	}
+	var buf strings.Builder
+	for start := 0; start < len(edits); {
+		// find the next change, and the last one close enough after it
+		first := start
+		for first < len(edits) && edits[first].op == '=' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		last := first
+		for k := first + 1; k < len(edits) && k <= last+2*ctx+1; k++ {
+			if edits[k].op != '=' {
+				last = k
+			}
+		}
+		from, to := first-ctx, last+ctx+1
+		if from < start {
+			from = start
+		}
+		if to > len(edits) {
+			to = len(edits)
+		}
+		writeHunk(&buf, edits[from:to], al, bl)
+		start = to
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// writeHunk writes the hunk of edits to the lines al and bl in unified
+// diff form.
+func writeHunk(buf *strings.Builder, edits []edit, al, bl []string) {
+	var an, bn int
+	for _, e := range edits {
+		if e.op != '+' {
+			an++
+		}
+		if e.op != '-' {
+			bn++
+		}
+	}
+	as, bs := edits[0].i+1, edits[0].j+1
+	if an == 0 {
+		as--
+	}
+	if bn == 0 {
+		bs--
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", as, an, bs, bn)
+	for _, e := range edits {
+		switch e.op {
+		case '=':
+			writeLine(buf, ' ', al[e.i])
+		case '-':
+			writeLine(buf, '-', al[e.i])
+		case '+':
+			writeLine(buf, '+', bl[e.j])
+		}
+	}
+}
+
+// writeLine writes a line of a hunk, noting when it is a last line with
+// no newline the way diff(1) does.
+func writeLine(buf *strings.Builder, op byte, line string) {
+	buf.WriteByte(op)
+	buf.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		buf.WriteString("\n\\ No newline at end of string\n")
+	}
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
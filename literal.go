@@ -0,0 +1,300 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	stringType     = reflect.TypeOf("")
+	float64Type    = reflect.TypeOf(float64(0))
+	complex128Type = reflect.TypeOf(complex128(0))
+)
+
+// LiteralError is returned by Literal when parts of a value can't be
+// expressed as Go source, it lists each of them as the path to the part
+// followed by the reason (eg: ".Done: non-nil chan values can't be
+// expressed").
+type LiteralError struct {
+	Problems []string
+}
+
+func (e *LiteralError) Error() string {
+	return "pretty: can't express value as Go: " + strings.Join(e.Problems, "; ")
+}
+
+// Literal returns x formatted as a gofmt-able, compilable Go expression,
+// fit for pasting into a test fixture.  Pointers to values other than
+// composite literals are taken inside function literals, NaN and infinite
+// floats use package math and so on.  If some part of x can't be
+// expressed (a non-nil channel or function, a cycle, an unexported field
+// or type, or anything elided by the depth and truncation limits) the
+// returned error is a *LiteralError listing them, the expression is still
+// returned with nil or zero values standing in for those parts.
+func (c *Config) Literal(x interface{}) (string, error) {
+	lc := *c
+	lc.Humanize = false
+	lc.Compilable = true
+	var buf bytes.Buffer
+	lit := new(literalState)
+	lc.render(&buf, reflect.ValueOf(x), true, lit)
+	if len(lit.problems) > 0 {
+		return buf.String(), &LiteralError{lit.problems}
+	}
+	return buf.String(), nil
+}
+
+// Literal is like Config.Literal but uses the default configuration.
+func Literal(x interface{}) (string, error) {
+	return defaultConfig.Literal(x)
+}
+
+// literalState collects what is learned while producing compilable
+// output, it is shared by all the printers used for one value.
+type literalState struct {
+	pkgPath  string            // import path of the package being generated
+	imports  map[string]string // import path to package name
+	problems []string
+	reported map[string]bool
+}
+
+// problem notes, when compilable output is being produced, that the value
+// being printed can't be expressed for the reason given.
+func (p *printer) problem(format string, a ...interface{}) {
+	if p.lit == nil {
+		return
+	}
+	msg := pathName(p.path) + ": " + fmt.Sprintf(format, a...)
+	if p.lit.reported[msg] {
+		return
+	}
+	if p.lit.reported == nil {
+		p.lit.reported = make(map[string]bool)
+	}
+	p.lit.reported[msg] = true
+	p.lit.problems = append(p.lit.problems, msg)
+}
+
+// goType returns the Go source for type t, as seen from the package
+// being generated: types of that package are not qualified, the packages
+// of all the others are imported.  It notes the named types within t that
+// can't be written outside of their own package.
+func (p *printer) goType(t reflect.Type) string {
+	if t.Name() != "" {
+		s := t.String()
+		switch {
+		case t.PkgPath() == "":
+			// predeclared
+		case t.PkgPath() == p.lit.pkgPath:
+			s = s[strings.Index(s, ".")+1:]
+		case !isExported(t.Name()):
+			p.problem("unexported type %s can't be named", t)
+		default:
+			p.usePackage(t.PkgPath(), s[:strings.Index(s, ".")])
+		}
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), p.goType(t.Elem()))
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + p.goType(t.Elem())
+		case reflect.SendDir:
+			return "chan<- " + p.goType(t.Elem())
+		}
+		if t.Elem().Kind() == reflect.Chan && t.Elem().ChanDir() == reflect.RecvDir {
+			return "chan (" + p.goType(t.Elem()) + ")"
+		}
+		return "chan " + p.goType(t.Elem())
+	case reflect.Func:
+		in := make([]string, t.NumIn())
+		for i := range in {
+			in[i] = p.goType(t.In(i))
+		}
+		if t.IsVariadic() {
+			in[len(in)-1] = "..." + p.goType(t.In(len(in)-1).Elem())
+		}
+		out := make([]string, t.NumOut())
+		for i := range out {
+			out[i] = p.goType(t.Out(i))
+		}
+		s := "func(" + strings.Join(in, ", ") + ")"
+		switch len(out) {
+		case 0:
+		case 1:
+			s += " " + out[0]
+		default:
+			s += " (" + strings.Join(out, ", ") + ")"
+		}
+		return s
+	case reflect.Interface:
+		for i := 0; i < t.NumMethod(); i++ {
+			p.goType(t.Method(i).Type)
+		}
+	case reflect.Map:
+		return "map[" + p.goType(t.Key()) + "]" + p.goType(t.Elem())
+	case reflect.Ptr:
+		return "*" + p.goType(t.Elem())
+	case reflect.Slice:
+		return "[]" + p.goType(t.Elem())
+	case reflect.Struct:
+		if t.NumField() == 0 {
+			return "struct {}"
+		}
+		fields := make([]string, t.NumField())
+		for i := range fields {
+			f := t.Field(i)
+			fields[i] = p.goType(f.Type)
+			if !f.Anonymous {
+				fields[i] = f.Name + " " + fields[i]
+			}
+			if f.Tag != "" {
+				fields[i] += " " + strconv.Quote(string(f.Tag))
+			}
+		}
+		return "struct { " + strings.Join(fields, "; ") + " }"
+	}
+	return t.String()
+}
+
+// usePackage notes that the generated source refers to the package with
+// the given import path by the given name.
+func (p *printer) usePackage(path, name string) {
+	if p.lit == nil || path == p.lit.pkgPath {
+		return
+	}
+	if p.lit.imports == nil {
+		p.lit.imports = make(map[string]string)
+	}
+	p.lit.imports[path] = name
+}
+
+// settable reports whether field i of struct type t can be given in a
+// composite literal, noting a problem if a value is lost by leaving it out.
+func (p *printer) settable(t reflect.Type, i int, v reflect.Value) bool {
+	f := t.Field(i)
+	switch {
+	case f.Name == "_":
+		return false
+	case f.PkgPath == "", f.PkgPath == p.lit.pkgPath:
+		return true
+	}
+	if nonzero(v) {
+		p.problem("unexported field of %s can't be set", t)
+	}
+	return false
+}
+
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// floatLiteral returns a Go expression for f, using package math for NaN
+// and the infinities.
+func floatLiteral(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()"
+	case math.IsInf(f, 1):
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func isSpecial(f float64) bool {
+	return math.IsNaN(f) || math.IsInf(f, 0)
+}
+
+// printFloatLiteral prints NaN and infinite floats (which have no literal
+// form) as compilable expressions, returning false for any other float.
+func (p *printer) printFloatLiteral(v reflect.Value) bool {
+	f := v.Float()
+	if !isSpecial(f) {
+		return false
+	}
+	p.usePackage("math", "math")
+	expr := floatLiteral(f)
+	if v.Type() != float64Type {
+		expr = p.typeString(v.Type()) + "(" + expr + ")"
+	}
+	p.writeString(expr)
+	return true
+}
+
+// printComplexLiteral prints a complex number as a compilable expression,
+// converted to its type where the untyped constant would lose it.
+func (p *printer) printComplexLiteral(v reflect.Value, showType bool) {
+	c := v.Complex()
+	expr := fmt.Sprintf("%#v", c)
+	if isSpecial(real(c)) || isSpecial(imag(c)) {
+		p.usePackage("math", "math")
+		expr = "complex(" + floatLiteral(real(c)) + ", " + floatLiteral(imag(c)) + ")"
+		showType = true
+	}
+	if showType && v.Type() != complex128Type {
+		expr = p.typeString(v.Type()) + "(" + expr + ")"
+	}
+	p.writeString(expr)
+}
+
+// printPointerLiteral prints a non-nil pointer as a compilable expression,
+// the address can only be taken directly of a composite literal so other
+// values are declared, and their address taken, in a function literal.
+func (p *printer) printPointerLiteral(v reflect.Value) {
+	e := v.Elem()
+	if p.isCompositeLiteral(e) {
+		p.writeByte('&')
+		p.printValue(e, true, true)
+		return
+	}
+	p.writeString("func() " + p.typeString(v.Type()) + " { var v " + p.typeString(e.Type()) + " = ")
+	p.printValue(e, true, true)
+	p.writeString("; return &v }()")
+}
+
+// isCompositeLiteral reports whether v will be printed as a composite
+// literal (eg: "T{...}") rather than by a Renderer or method.
+func (p *printer) isCompositeLiteral(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return false
+		}
+	case reflect.Struct, reflect.Array:
+	default:
+		return false
+	}
+	if p.c.renderer(v.Type()) != nil {
+		return false
+	}
+	if p.c.CallMethods && v.Type().Implements(goStringerType) {
+		return false
+	}
+	return true
+}
+
+var goStringerType = reflect.TypeOf((*fmt.GoStringer)(nil)).Elem()
+
+// printNilLiteral prints a channel, function or unsafe pointer, none of
+// which can be expressed in Go source unless they are nil.
+func (p *printer) printNilLiteral(v reflect.Value, showType bool) {
+	if !v.IsNil() {
+		p.problem("non-nil %s values can't be expressed", v.Kind())
+	}
+	if showType {
+		p.writeString("(" + p.typeString(v.Type()) + ")(nil)")
+	} else {
+		p.writeString("nil")
+	}
+}
@@ -0,0 +1,168 @@
+package pretty
+
+import (
+	"go/parser"
+	"math"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Fixture struct {
+	Name    string
+	Count   *int
+	Ratio   float32
+	Score   float64
+	Z       complex64
+	Tags    []string
+	Attrs   map[string]interface{}
+	Inner   T
+	PInner  *T
+	Nested  *[]int
+	Any     interface{}
+	When    *time.Time
+	Big     *big.Int
+	Link    url.URL
+	Done    chan bool
+	OnClose func()
+	hidden  int
+}
+
+var literaltests = []struct {
+	v        interface{}
+	s        string
+	problems []string
+}{
+	{1, "int(1)", nil},
+	{"a", `"a"`, nil},
+	{F(5), "pretty.F(5)", nil},
+	{math.NaN(), "math.NaN()", nil},
+	{float32(math.Inf(-1)), "float32(math.Inf(-1))", nil},
+	{complex64(complex(1, 2)), "complex64((1+2i))", nil},
+	{complex(math.Inf(1), 0), "complex(math.Inf(1), 0)", nil},
+	{map[float64]int{math.NaN(): 1, 2: 3}, "map[float64]int{math.NaN():1, 2:3}", nil},
+	{new(int), "func() *int { var v int = int(0); return &v }()", nil},
+	{&T{1, 2}, "&pretty.T{}", []string{".x: unexported field of pretty.T can't be set", ".y: unexported field of pretty.T can't be set"}},
+	{[]interface{}{F(1), Str("x"), nil}, "[]interface {}{\n    pretty.F(1),\n    pretty.Str(\"x\"),\n    nil,\n}", nil},
+	{map[interface{}]bool{Str("k"): true}, `map[interface {}]bool{pretty.Str("k"):true}`, nil},
+	{(chan int)(nil), "(chan int)(nil)", nil},
+	{make(chan int), "(chan int)(nil)", []string{".: non-nil chan values can't be expressed"}},
+	{struct{ f func() }{}, "struct { f func() }{}", nil},
+	{
+		Fixture{
+			Name:    "n",
+			Count:   new(int),
+			Ratio:   float32(math.NaN()),
+			Score:   math.Inf(1),
+			Z:       1,
+			Tags:    []string{"a"},
+			Attrs:   map[string]interface{}{"k": 1.5},
+			Inner:   T{},
+			PInner:  &T{},
+			Nested:  &[]int{1},
+			Any:     []int(nil),
+			When:    &when,
+			Big:     big.NewInt(7),
+			Link:    url.URL{Scheme: "http", Host: "h"},
+			Done:    make(chan bool),
+			OnClose: func() {},
+			hidden:  1,
+		},
+		`pretty.Fixture{
+    Name:  "n",
+    Count: func() *int { var v int = int(0); return &v }(),
+    Ratio: float32(math.NaN()),
+    Score: math.Inf(1),
+    Z:     (1+0i),
+    Tags:  []string{"a"},
+    Attrs: map[string]interface {}{
+        "k": float64(1.5),
+    },
+    Inner:   pretty.T{},
+    PInner:  &pretty.T{},
+    Nested:  &[]int{1},
+    Any:     []int(nil),
+    When:    func() *time.Time { var v time.Time = time.Date(2024, 1, 2, 15, 4, 5, 600, time.UTC); return &v }(),
+    Big:     func() *big.Int { v, _ := new(big.Int).SetString("7", 10); return v }(),
+    Link:    *func() *url.URL { v, _ := url.Parse("http://h"); return v }(),
+    Done:    nil,
+    OnClose: nil,
+}`,
+		[]string{
+			".Done: non-nil chan values can't be expressed",
+			".OnClose: non-nil func values can't be expressed",
+			".hidden: unexported field of pretty.Fixture can't be set",
+		},
+	},
+	{
+		[]interface{}{hiddenType{}},
+		"[]interface {}{\n    pretty.hiddenType{},\n}",
+		[]string{".[0]: unexported type pretty.hiddenType can't be named"},
+	},
+}
+
+type Str string
+
+type hiddenType struct{}
+
+func TestLiteral(t *testing.T) {
+	for _, tt := range literaltests {
+		s, err := Literal(tt.v)
+		if s != tt.s {
+			t.Errorf("expected %q", tt.s)
+			t.Errorf("got      %q", s)
+			t.Errorf("gotraw\n%s", s)
+		}
+		if _, perr := parser.ParseExpr(s); perr != nil {
+			t.Errorf("Literal(%#v) is not a Go expression: %v\n%s", tt.v, perr, s)
+		}
+		var problems []string
+		if err != nil {
+			problems = err.(*LiteralError).Problems
+		}
+		if !reflect.DeepEqual(problems, tt.problems) {
+			t.Errorf("Literal(%#v) problems = %q want %q", tt.v, problems, tt.problems)
+		}
+	}
+}
+
+func TestLiteralLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, tt := range []struct {
+		v        time.Time
+		s        string
+		problems []string
+	}{
+		{time.Date(2024, 7, 1, 12, 0, 0, 0, ny), `time.Date(2024, 7, 1, 12, 0, 0, 0, time.FixedZone("EDT", -14400))`, []string{
+			`.: location "America/New_York" is written as a fixed zone, without its daylight saving rules`,
+		}},
+		{time.Date(2024, 7, 1, 12, 0, 0, 0, time.FixedZone("EDT", -14400)), `time.Date(2024, 7, 1, 12, 0, 0, 0, time.FixedZone("EDT", -14400))`, nil},
+	} {
+		s, err := Literal(tt.v)
+		if s != tt.s {
+			t.Errorf("Literal(%v) = %s want %s", tt.v, s, tt.s)
+		}
+		var problems []string
+		if err != nil {
+			problems = err.(*LiteralError).Problems
+		}
+		if !reflect.DeepEqual(problems, tt.problems) {
+			t.Errorf("Literal(%v) problems = %q want %q", tt.v, problems, tt.problems)
+		}
+	}
+}
+
+func TestLiteralLimits(t *testing.T) {
+	c := NewConfig()
+	c.MaxElements = 1
+	_, err := c.Literal([]int{1, 2})
+	if err == nil || !strings.Contains(err.Error(), "truncated, 1 more element") {
+		t.Errorf("Literal with MaxElements error = %v", err)
+	}
+}
@@ -5,6 +5,9 @@
 // It provides a function, Formatter, that can be used with any
 // function that accepts a format string. It also provides
 // convenience wrappers for functions in packages fmt and log.
+//
+// All of the package-level functions share one default set of
+// options; use a Config to hold an independent set.
 package pretty
 
 import (
@@ -21,8 +24,7 @@ import (
 // Calling Errorf(f, x, y) is equivalent to
 // fmt.Errorf(f, Formatter(x), Formatter(y)).
 func Errorf(format string, a ...interface{}) error {
-	str := text.Indent(fmt.Sprintf(format, wrap(a, false)...), outputPrefixStr)
-	return fmt.Errorf("%s", str)
+	return defaultConfig.Errorf(format, a...)
 }
 
 // Fprintf is a convenience wrapper for fmt.Fprintf.
@@ -30,8 +32,7 @@ func Errorf(format string, a ...interface{}) error {
 // Calling Fprintf(w, f, x, y) is equivalent to
 // fmt.Fprintf(w, f, Formatter(x), Formatter(y)).
 func Fprintf(w io.Writer, format string, a ...interface{}) (n int, error error) {
-	str := text.Indent(fmt.Sprintf(format, wrap(a, false)...), outputPrefixStr)
-	return fmt.Fprint(w, str)
+	return defaultConfig.Fprintf(w, format, a...)
 }
 
 // Log is a convenience wrapper for log.Printf.
@@ -40,8 +41,7 @@ func Fprintf(w io.Writer, format string, a ...interface{}) (n int, error error)
 // log.Print(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Log(a ...interface{}) {
-	str := text.Indent(fmt.Sprint(wrap(a, true)...), outputPrefixStr)
-	log.Print(str)
+	defaultConfig.Log(a...)
 }
 
 // Logf is a convenience wrapper for log.Printf.
@@ -49,8 +49,7 @@ func Log(a ...interface{}) {
 // Calling Logf(f, x, y) is equivalent to
 // log.Printf(f, Formatter(x), Formatter(y)).
 func Logf(format string, a ...interface{}) {
-	str := text.Indent(fmt.Sprintf(format, wrap(a, false)...), outputPrefixStr)
-	log.Print(str)
+	defaultConfig.Logf(format, a...)
 }
 
 // Logln is a convenience wrapper for log.Printf.
@@ -59,8 +58,7 @@ func Logf(format string, a ...interface{}) {
 // log.Println(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Logln(a ...interface{}) {
-	str := text.Indent(fmt.Sprintln(wrap(a, true)...), outputPrefixStr)
-	log.Print(str)
+	defaultConfig.Logln(a...)
 }
 
 // Print pretty-prints its operands and writes to standard output.
@@ -69,8 +67,7 @@ func Logln(a ...interface{}) {
 // fmt.Print(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Print(a ...interface{}) (n int, errno error) {
-	str := text.Indent(fmt.Sprint(wrap(a, true)...), outputPrefixStr)
-	return fmt.Print(str)
+	return defaultConfig.Print(a...)
 }
 
 // Printf is a convenience wrapper for fmt.Printf.
@@ -78,8 +75,7 @@ func Print(a ...interface{}) (n int, errno error) {
 // Calling Printf(f, x, y) is equivalent to
 // fmt.Printf(f, Formatter(x), Formatter(y)).
 func Printf(format string, a ...interface{}) (n int, errno error) {
-	str := text.Indent(fmt.Sprintf(format, wrap(a, false)...), outputPrefixStr)
-	return fmt.Print(str)
+	return defaultConfig.Printf(format, a...)
 }
 
 // Println pretty-prints its operands and writes to standard output.
@@ -88,8 +84,7 @@ func Printf(format string, a ...interface{}) (n int, errno error) {
 // fmt.Println(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Println(a ...interface{}) (n int, errno error) {
-	str := text.Indent(fmt.Sprintln(wrap(a, true)...), outputPrefixStr)
-	return fmt.Println(str)
+	return defaultConfig.Println(a...)
 }
 
 // Sprint is a convenience wrapper for fmt.Sprintf.
@@ -98,7 +93,7 @@ func Println(a ...interface{}) (n int, errno error) {
 // fmt.Sprint(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Sprint(a ...interface{}) string {
-	return fmt.Sprint(wrap(a, true)...)
+	return defaultConfig.Sprint(a...)
 }
 
 // Sprintf is a convenience wrapper for fmt.Sprintf.
@@ -106,7 +101,7 @@ func Sprint(a ...interface{}) string {
 // Calling Sprintf(f, x, y) is equivalent to
 // fmt.Sprintf(f, Formatter(x), Formatter(y)).
 func Sprintf(format string, a ...interface{}) string {
-	return text.Indent(fmt.Sprintf(format, wrap(a, false)...), outputPrefixStr)
+	return defaultConfig.Sprintf(format, a...)
 }
 
 // Sprintln is a convenience wrapper for fmt.Sprintln.
@@ -114,13 +109,76 @@ func Sprintf(format string, a ...interface{}) string {
 // Calling Sprintln(x, y) is equivalent to
 // fmt.Sprintln(Formatter(x), Formatter(y)).
 func Sprintln(a ...interface{}) string {
-	return text.Indent(fmt.Sprintln(wrap(a, false)...), outputPrefixStr)
+	return defaultConfig.Sprintln(a...)
+}
+
+// Errorf is like the package-level Errorf but uses the options in c.
+func (c *Config) Errorf(format string, a ...interface{}) error {
+	str := text.Indent(fmt.Sprintf(format, c.wrap(a, false)...), c.OutputPrefixStr)
+	return fmt.Errorf("%s", str)
+}
+
+// Fprintf is like the package-level Fprintf but uses the options in c.
+func (c *Config) Fprintf(w io.Writer, format string, a ...interface{}) (n int, error error) {
+	str := text.Indent(fmt.Sprintf(format, c.wrap(a, false)...), c.OutputPrefixStr)
+	return fmt.Fprint(w, str)
+}
+
+// Log is like the package-level Log but uses the options in c.
+func (c *Config) Log(a ...interface{}) {
+	str := text.Indent(fmt.Sprint(c.wrap(a, true)...), c.OutputPrefixStr)
+	log.Print(str)
+}
+
+// Logf is like the package-level Logf but uses the options in c.
+func (c *Config) Logf(format string, a ...interface{}) {
+	str := text.Indent(fmt.Sprintf(format, c.wrap(a, false)...), c.OutputPrefixStr)
+	log.Print(str)
+}
+
+// Logln is like the package-level Logln but uses the options in c.
+func (c *Config) Logln(a ...interface{}) {
+	str := text.Indent(fmt.Sprintln(c.wrap(a, true)...), c.OutputPrefixStr)
+	log.Print(str)
+}
+
+// Print is like the package-level Print but uses the options in c.
+func (c *Config) Print(a ...interface{}) (n int, errno error) {
+	str := text.Indent(fmt.Sprint(c.wrap(a, true)...), c.OutputPrefixStr)
+	return fmt.Print(str)
+}
+
+// Printf is like the package-level Printf but uses the options in c.
+func (c *Config) Printf(format string, a ...interface{}) (n int, errno error) {
+	str := text.Indent(fmt.Sprintf(format, c.wrap(a, false)...), c.OutputPrefixStr)
+	return fmt.Print(str)
+}
+
+// Println is like the package-level Println but uses the options in c.
+func (c *Config) Println(a ...interface{}) (n int, errno error) {
+	str := text.Indent(fmt.Sprintln(c.wrap(a, true)...), c.OutputPrefixStr)
+	return fmt.Println(str)
+}
+
+// Sprint is like the package-level Sprint but uses the options in c.
+func (c *Config) Sprint(a ...interface{}) string {
+	return fmt.Sprint(c.wrap(a, true)...)
+}
+
+// Sprintf is like the package-level Sprintf but uses the options in c.
+func (c *Config) Sprintf(format string, a ...interface{}) string {
+	return text.Indent(fmt.Sprintf(format, c.wrap(a, false)...), c.OutputPrefixStr)
+}
+
+// Sprintln is like the package-level Sprintln but uses the options in c.
+func (c *Config) Sprintln(a ...interface{}) string {
+	return text.Indent(fmt.Sprintln(c.wrap(a, false)...), c.OutputPrefixStr)
 }
 
-func wrap(a []interface{}, force bool) []interface{} {
+func (c *Config) wrap(a []interface{}, force bool) []interface{} {
 	w := make([]interface{}, len(a))
 	for i, x := range a {
-		w[i] = formatter{v: reflect.ValueOf(x), force: force}
+		w[i] = formatter{c: c, v: reflect.ValueOf(x), force: force}
 	}
 	return w
 }
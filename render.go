@@ -0,0 +1,175 @@
+package pretty
+
+import (
+	"io"
+	"path"
+	"reflect"
+)
+
+// A Renderer prints v, a value of a type it has been registered for (see
+// Config.Register), to p.  It may write inline text to p or hand parts of
+// v back to p.PrintValue for nested structured output.  Renderers are
+// never called for nil pointers, maps or slices, which print as usual,
+// nor for values pretty can't read with v.Interface() (it can read most
+// unexported struct fields).  A Renderer must not pass v itself to
+// p.PrintValue.
+type Renderer func(p Printer, v reflect.Value)
+
+// Printer is the handle a Renderer writes its output to.
+type Printer interface {
+	// Write writes inline text into the output.
+	io.Writer
+
+	// PrintValue prints v, one level deeper than the value being
+	// rendered, the same way pretty prints any other value (including
+	// using registered renderers).  The type of v is only shown in Go
+	// syntax output and only if showType is true.
+	PrintValue(v reflect.Value, showType bool)
+
+	// Humanize reports whether humanized output is being produced.
+	Humanize() bool
+
+	// Compilable reports whether the output must be a compilable Go
+	// expression (see Config.Literal).
+	Compilable() bool
+
+	// TypeString returns type t the way it is written in the output, in
+	// compilable output this qualifies it as needed and notes the import.
+	TypeString(t reflect.Type) string
+
+	// UsePackage notes that compilable output refers to the package with
+	// the given import path, so a generated file imports it (see
+	// Config.WriteFixture).
+	UsePackage(path string)
+
+	// Problem notes that compilable output doesn't express the value
+	// being rendered exactly, for the reason given, so Config.Literal
+	// reports it in its LiteralError.  It does nothing in other output.
+	Problem(format string, a ...interface{})
+}
+
+// ifaceRenderer is a Renderer registered for an interface type.
+type ifaceRenderer struct {
+	t reflect.Type
+	r Renderer
+}
+
+// Register arranges for values of type t to be printed by r, wherever
+// they appear, in both Go syntax and humanized output.  If t is an
+// interface type then r is used for values of any type implementing t
+// that has no renderer of its own, interfaces are tried in the order
+// they were registered.  A nil r removes the registration for t.
+//
+// Register must not be called while c is being used to print.
+func (c *Config) Register(t reflect.Type, r Renderer) {
+	if t.Kind() == reflect.Interface {
+		for i, ir := range c.ifaceRenderers {
+			if ir.t == t {
+				c.ifaceRenderers = append(c.ifaceRenderers[:i], c.ifaceRenderers[i+1:]...)
+				break
+			}
+		}
+		if r != nil {
+			c.ifaceRenderers = append(c.ifaceRenderers, ifaceRenderer{t, r})
+		}
+		return
+	}
+	if r == nil {
+		delete(c.renderers, t)
+		return
+	}
+	if c.renderers == nil {
+		c.renderers = make(map[reflect.Type]Renderer)
+	}
+	c.renderers[t] = r
+}
+
+// Register is like Config.Register but for the default configuration
+// used by the package-level functions.
+func Register(t reflect.Type, r Renderer) {
+	defaultConfig.Register(t, r)
+}
+
+// renderer returns the Renderer registered with c for values of type t,
+// falling back on the built-in ones for well-known standard library
+// types, or nil if there is none.
+func (c *Config) renderer(t reflect.Type) Renderer {
+	if r, ok := c.renderers[t]; ok {
+		return r
+	}
+	for _, ir := range c.ifaceRenderers {
+		if t.Implements(ir.t) {
+			return ir.r
+		}
+	}
+	if r, ok := builtinRenderers[t]; ok {
+		return r
+	}
+	for _, ir := range builtinIfaceRenderers {
+		if t.Implements(ir.t) {
+			return ir.r
+		}
+	}
+	return nil
+}
+
+// printRendered prints v using the Renderer registered for its type,
+// returning false if there is no such Renderer.
+func (p *printer) printRendered(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		return false // the dynamic value gets its turn
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return false
+		}
+	}
+	r := p.c.renderer(v.Type())
+	if r == nil {
+		return false
+	}
+	x, ok := interfaceOf(v)
+	if !ok {
+		return false
+	}
+	r(renderPrinter{p}, reflect.ValueOf(x))
+	return true
+}
+
+// renderPrinter is the Printer handed to a Renderer, its writes go
+// through the printer so humanized output keeps track of its lines.
+type renderPrinter struct {
+	p *printer
+}
+
+func (r renderPrinter) Write(b []byte) (int, error) {
+	r.p.writeString(string(b))
+	return len(b), nil
+}
+
+func (r renderPrinter) PrintValue(v reflect.Value, showType bool) {
+	r.p.deeper().printValue(v, showType, true)
+}
+
+func (r renderPrinter) Humanize() bool {
+	return r.p.c.Humanize
+}
+
+func (r renderPrinter) Compilable() bool {
+	return r.p.lit != nil
+}
+
+func (r renderPrinter) TypeString(t reflect.Type) string {
+	return r.p.typeString(t)
+}
+
+func (r renderPrinter) UsePackage(importPath string) {
+	r.p.usePackage(importPath, path.Base(importPath))
+}
+
+func (r renderPrinter) Problem(format string, a ...interface{}) {
+	r.p.problem(format, a...)
+}
@@ -0,0 +1,89 @@
+package pretty
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type Money struct {
+	cents int64
+	cur   string
+}
+
+type Color int
+
+func (c Color) EnumName() string { return [...]string{"RED", "GREEN"}[c] }
+
+type enum interface {
+	EnumName() string
+}
+
+type Box struct {
+	v interface{}
+}
+
+type Order struct {
+	Price  Money
+	Color  Color
+	Box    *Box
+	NilBox *Box
+}
+
+func newRenderConfig() *Config {
+	c := NewConfig()
+	c.Register(reflect.TypeOf(Money{}), func(p Printer, v reflect.Value) {
+		m := v.Interface().(Money)
+		fmt.Fprintf(p, "%d.%02d %s", m.cents/100, m.cents%100, m.cur)
+	})
+	c.Register(reflect.TypeOf((*enum)(nil)).Elem(), func(p Printer, v reflect.Value) {
+		fmt.Fprint(p, v.Interface().(enum).EnumName())
+	})
+	c.Register(reflect.TypeOf(&Box{}), func(p Printer, v reflect.Value) {
+		if !p.Humanize() {
+			fmt.Fprint(p, "Box(")
+		}
+		p.PrintValue(v.Elem().Field(0), true)
+		if !p.Humanize() {
+			fmt.Fprint(p, ")")
+		}
+	})
+	return c
+}
+
+func TestRegister(t *testing.T) {
+	v := Order{
+		Price: Money{1234, "EUR"},
+		Color: 1,
+		Box:   &Box{T{1, 2}},
+	}
+	c := newRenderConfig()
+	want := `pretty.Order{
+    Price:  12.34 EUR,
+    Color:  GREEN,
+    Box:    Box(pretty.T{x:1, y:2}),
+    NilBox: (*pretty.Box)(nil),
+}`
+	if s := fmt.Sprintf("%# v", c.Formatter(v)); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+
+	c.Humanize = true
+	c.OutputIndentLevel = 2
+	want = "Price: 12.34 EUR\nColor: GREEN\nBox:   \n  x: 1\n  y: 2\nNilBox: nil\n"
+	if s := fmt.Sprintf("%# v", c.Formatter(v)); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+
+	c.Register(reflect.TypeOf(Money{}), nil)
+	c.Register(reflect.TypeOf((*enum)(nil)).Elem(), nil)
+	c.Humanize = false
+	c.OutputIndentLevel = 4
+	want = "[]interface {}{\n    pretty.Money{cents:1234, cur:\"EUR\"},\n    pretty.Color(1),\n}"
+	if s := fmt.Sprintf("%# v", c.Formatter([]interface{}{v.Price, v.Color})); s != want {
+		t.Errorf("expected %q", want)
+		t.Errorf("got      %q", s)
+	}
+}
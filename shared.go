@@ -0,0 +1,170 @@
+package pretty
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// labelState numbers the pointers, maps and slices that a value refers to
+// more than once, so that Go syntax output can print each of them in full
+// once (eg: "#1=&pkg.T{...}") and print just its label ("#1") wherever it
+// is referred to again (see Config.LabelShared).
+type labelState struct {
+	refs   map[ref]int // how many times each was found
+	labels map[ref]int // the labels given out so far
+}
+
+// ref identifies what a pointer, map or slice refers to, slices of the
+// same array are only the same if they have the same length.
+type ref struct {
+	p uintptr
+	n int
+	t reflect.Type
+}
+
+// refOf returns the ref for v, which is false for values that can't be
+// shared: nil ones, empty slices, pointers to zero-sized values (which
+// may share their address with others) and all other kinds.
+func refOf(v reflect.Value) (ref, bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type().Elem().Size() == 0 {
+			return ref{}, false
+		}
+		return ref{p: v.Pointer(), t: v.Type()}, true
+	case reflect.Map:
+		if v.IsNil() {
+			return ref{}, false
+		}
+		return ref{p: v.Pointer(), t: v.Type()}, true
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return ref{}, false
+		}
+		return ref{p: v.Pointer(), n: v.Len(), t: v.Type()}, true
+	}
+	return ref{}, false
+}
+
+// scanShared finds the pointers, maps and slices within v that are
+// referred to more than once where they are printed in full: values
+// printed by a Renderer or a method, elided (see Config.MaxDepth) or cut
+// off (see Config.MaxElements) aren't looked into.
+func (c *Config) scanShared(v reflect.Value) *labelState {
+	s := &labelState{refs: make(map[ref]int), labels: make(map[ref]int)}
+	s.scan(c, v, 0)
+	return s
+}
+
+func (s *labelState) scan(c *Config, v reflect.Value, depth int) {
+	if !v.IsValid() || c.elided(v, depth) {
+		return
+	}
+	if v.Kind() != reflect.Interface && c.renderer(v.Type()) != nil {
+		return
+	}
+	if c.CallMethods && v.Kind() != reflect.Interface && v.Type().Implements(goStringerType) {
+		return
+	}
+	if r, ok := refOf(v); ok {
+		s.refs[r]++
+		if s.refs[r] > 1 {
+			return // its contents have been counted already
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		s.scan(c, v.Elem(), depth)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			s.scan(c, v.Field(i), depth+1)
+		}
+	case reflect.Array, reflect.Slice:
+		n := v.Len()
+		if max := c.MaxElements; max > 0 && n > max {
+			n = max
+		}
+		for i := 0; i < n; i++ {
+			s.scan(c, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		keys, vals := mapEntries(v, false)
+		if max := c.MaxMapEntries; max > 0 && len(keys) > max {
+			if !c.SortMapKeys {
+				return // which entries get printed is up to chance
+			}
+			sort.Stable(entries{keys, vals})
+			keys = keys[:max]
+		}
+		for i, k := range keys {
+			s.scan(c, k, depth+1)
+			s.scan(c, vals[i], depth+1)
+		}
+	}
+}
+
+// elided reports whether v, printed at the given depth, is elided (see
+// Config.MaxDepth), or is a pointer to a value that is.
+func (c *Config) elided(v reflect.Value, depth int) bool {
+	if c.MaxDepth <= 0 || depth < c.MaxDepth {
+		return false
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return v.NumField() > 0
+	case reflect.Map, reflect.Array, reflect.Slice:
+		return v.Len() > 0
+	}
+	return false
+}
+
+// printLabel prints the label of v, when v is shared, returning true if
+// v has been printed already and so needs nothing more than that.
+func (p *printer) printLabel(v reflect.Value) bool {
+	r, ok := refOf(v)
+	if !ok || p.shared.refs[r] < 2 {
+		return false
+	}
+	if n, ok := p.shared.labels[r]; ok {
+		fmt.Fprintf(p, "#%d", n)
+		return true
+	}
+	n := len(p.shared.labels) + 1
+	p.shared.labels[r] = n
+	fmt.Fprintf(p, "#%d=", n)
+	return false
+}
+
+// ancestor is one of the pointers, maps and slices that the value being
+// printed is within, linked up towards the value at the root, a value
+// that refers to one of its ancestors is part of a cycle.
+type ancestor struct {
+	up   *ancestor
+	r    ref
+	path *step // to where the ancestor was printed
+}
+
+// find returns the ancestor referring to what r does, or nil.
+func (a *ancestor) find(r ref) *ancestor {
+	for ; a != nil; a = a.up {
+		if a.r == r {
+			return a
+		}
+	}
+	return nil
+}
+
+// printCycle prints a marker, naming the path to ancestor a, in place of
+// a value that refers back to a.  Compilable output has nil in its place.
+func (p *printer) printCycle(a *ancestor) {
+	p.problem("cyclic reference to %s can't be expressed", pathName(a.path))
+	if p.lit != nil {
+		p.writeString("nil")
+		return
+	}
+	p.writeString("(CYCLIC REFERENCE to " + pathName(a.path) + ")")
+}
@@ -0,0 +1,163 @@
+package pretty
+
+import (
+	"math"
+	"reflect"
+	"sort"
+)
+
+// sortKeys sorts map keys in place into a stable, type-aware order so
+// the same map always prints (and diffs) the same way.
+func sortKeys(keys []reflect.Value) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compare(keys[i], keys[j]) < 0
+	})
+}
+
+// mapEntries returns the keys of map v and their values, sorted by key
+// if sorted is true.  Unlike MapIndex it gets the values of NaN keys.
+func mapEntries(v reflect.Value, sorted bool) (keys, vals []reflect.Value) {
+	for it := v.MapRange(); it.Next(); {
+		keys = append(keys, it.Key())
+		vals = append(vals, it.Value())
+	}
+	if sorted {
+		sort.Stable(entries{keys, vals})
+	}
+	return keys, vals
+}
+
+// entries sorts map keys, and their values with them, the way sortKeys
+// does.
+type entries struct {
+	keys, vals []reflect.Value
+}
+
+func (e entries) Len() int           { return len(e.keys) }
+func (e entries) Less(i, j int) bool { return compare(e.keys[i], e.keys[j]) < 0 }
+func (e entries) Swap(i, j int) {
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+	e.vals[i], e.vals[j] = e.vals[j], e.vals[i]
+}
+
+// compare returns -1, 0 or 1 as a sorts before, the same as or after b.
+// Numbers compare numerically, strings lexically, false sorts before
+// true, structs and arrays compare element by element and interfaces
+// compare by dynamic type name and then by value, much like the
+// ordering package fmt uses for its own map output.  Both values are
+// expected to be valid map keys of the same type.
+func compare(a, b reflect.Value) int {
+	if !a.IsValid() || !b.IsValid() {
+		return nilCompare(a.IsValid(), b.IsValid())
+	}
+	if a.Type() != b.Type() {
+		return cmpString(a.Type().String(), b.Type().String())
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, y := a.Int(), b.Int()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, y := a.Uint(), b.Uint()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case reflect.String:
+		return cmpString(a.String(), b.String())
+	case reflect.Float32, reflect.Float64:
+		return cmpFloat(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		x, y := a.Complex(), b.Complex()
+		if c := cmpFloat(real(x), real(y)); c != 0 {
+			return c
+		}
+		return cmpFloat(imag(x), imag(y))
+	case reflect.Bool:
+		x, y := a.Bool(), b.Bool()
+		switch {
+		case x == y:
+			return 0
+		case x:
+			return 1
+		}
+		return -1
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Chan:
+		x, y := a.Pointer(), b.Pointer()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if c := compare(a.Field(i), b.Field(i)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if c := compare(a.Index(i), b.Index(i)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return nilCompare(!a.IsNil(), !b.IsNil())
+		}
+		return compare(a.Elem(), b.Elem())
+	}
+	// not a valid map key type, leave the order alone
+	return 0
+}
+
+// nilCompare orders invalid (nil) values before valid ones.
+func nilCompare(aOK, bOK bool) int {
+	switch {
+	case aOK == bOK:
+		return 0
+	case aOK:
+		return 1
+	}
+	return -1
+}
+
+func cmpString(x, y string) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// cmpFloat orders NaN before every other value, including -Inf.
+func cmpFloat(x, y float64) int {
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return -1
+	case math.IsNaN(y):
+		return 1
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
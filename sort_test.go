@@ -0,0 +1,37 @@
+package pretty
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var sorttests = []struct {
+	keys interface{} // slice of keys, in the wanted order
+}{
+	{[]int{-3, 0, 7, 42}},
+	{[]uint8{0, 1, 200}},
+	{[]string{"", "A", "a", "ab", "b"}},
+	{[]float64{math.NaN(), math.Inf(-1), -1.5, 0, 2.5, math.Inf(1)}},
+	{[]complex128{complex(0, 1), complex(1, -1), complex(1, 0)}},
+	{[]bool{false, true}},
+	{[][2]int{{0, 9}, {1, 0}, {1, 2}}},
+	{[]T{{0, 5}, {1, 1}, {1, 2}}},
+	{[]interface{}{nil, 2, 10, "a", "b"}},
+}
+
+func TestSortKeys(t *testing.T) {
+	for _, tt := range sorttests {
+		want := reflect.ValueOf(tt.keys)
+		keys := make([]reflect.Value, want.Len())
+		for i := range keys {
+			keys[i] = want.Index(want.Len() - 1 - i)
+		}
+		sortKeys(keys)
+		for i, k := range keys {
+			if compare(k, want.Index(i)) != 0 {
+				t.Errorf("sortKeys(%v)[%d] = %v want %v", tt.keys, i, k, want.Index(i))
+			}
+		}
+	}
+}
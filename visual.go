@@ -0,0 +1,141 @@
+package pretty
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UnifiedDiff returns a unified diff ("-" and "+" lines, in hunks with
+// DiffContext unchanged lines around them) of a and b as Formatter prints
+// them, for test failure messages and code review comments.  Lines are
+// matched on structure: a struct field or map entry is paired with the
+// same one of the other value even when its value changed.  It returns ""
+// if a and b print the same.
+func UnifiedDiff(a, b interface{}) string {
+	return defaultConfig.UnifiedDiff(a, b)
+}
+
+// UnifiedDiff is like the package-level UnifiedDiff but uses the options
+// in c.
+func (c *Config) UnifiedDiff(a, b interface{}) string {
+	al, bl, edits := c.visualDiff(a, b)
+	for i := range al {
+		al[i] += "\n"
+	}
+	for j := range bl {
+		bl[j] += "\n"
+	}
+	return c.unified(al, bl, edits)
+}
+
+// SideBySideDiff returns a two-column diff of a and b as Formatter prints
+// them, lines matched the same way as by UnifiedDiff.  The columns are
+// separated by " | " for lines that changed, " < " and " > " for lines
+// only in a or b, and blanks for lines that are the same.  It returns ""
+// if a and b print the same.
+func SideBySideDiff(a, b interface{}) string {
+	return defaultConfig.SideBySideDiff(a, b)
+}
+
+// SideBySideDiff is like the package-level SideBySideDiff but uses the
+// options in c.
+func (c *Config) SideBySideDiff(a, b interface{}) string {
+	al, bl, edits := c.visualDiff(a, b)
+	changed := false
+	width := 0
+	for _, e := range edits {
+		if e.op != '=' {
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+	for _, l := range al {
+		if n := utf8.RuneCountInString(l); n > width {
+			width = n
+		}
+	}
+	var buf strings.Builder
+	row := func(left, sep, right string) {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(left))
+		buf.WriteString(strings.TrimRight(left+pad+sep+right, " ") + "\n")
+	}
+	var dels, ins []int
+	flush := func() {
+		k := 0
+		for ; k < len(dels) && k < len(ins); k++ {
+			row(al[dels[k]], " | ", bl[ins[k]])
+		}
+		for _, i := range dels[k:] {
+			row(al[i], " < ", "")
+		}
+		for _, j := range ins[k:] {
+			row("", " > ", bl[j])
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+	for _, e := range edits {
+		switch e.op {
+		case '=':
+			flush()
+			row(al[e.i], "   ", bl[e.j])
+		case '-':
+			dels = append(dels, e.i)
+		case '+':
+			ins = append(ins, e.j)
+		}
+	}
+	flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// visualDiff returns the lines of a and b as Formatter prints them and
+// their alignment, lines with the same key (see lineKey) are aligned, and
+// then replaced if they aren't the same.
+func (c *Config) visualDiff(a, b interface{}) (al, bl []string, edits []edit) {
+	al = strings.Split(fmt.Sprintf("%# v", c.Formatter(a)), "\n")
+	bl = strings.Split(fmt.Sprintf("%# v", c.Formatter(b)), "\n")
+	ak, bk := make([]string, len(al)), make([]string, len(bl))
+	for i, l := range al {
+		ak[i] = lineKey(l)
+	}
+	for j, l := range bl {
+		bk[j] = lineKey(l)
+	}
+	for _, e := range c.alignLines(len(al), len(bl), func(i, j int) bool { return ak[i] == bk[j] }) {
+		if e.op == '=' && al[e.i] != bl[e.j] {
+			edits = append(edits, edit{'-', e.i, e.j}, edit{'+', e.i + 1, e.j})
+			continue
+		}
+		edits = append(edits, e)
+	}
+	return al, bl, edits
+}
+
+// lineKey returns what identifies a line of Formatter output within its
+// value: the indent and name of a struct field or map key for lines that
+// start with one (eg: `    Name:`), otherwise the whole line.
+func lineKey(line string) string {
+	text := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(text)]
+	if strings.HasPrefix(text, `"`) {
+		q, err := strconv.QuotedPrefix(text)
+		if err == nil && strings.HasPrefix(text[len(q):], ":") {
+			return indent + q + ":"
+		}
+		return line
+	}
+	for i, r := range text {
+		if r == ':' && i > 0 {
+			return indent + text[:i+1]
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_.-+", r) {
+			break
+		}
+	}
+	return line
+}
@@ -0,0 +1,77 @@
+package pretty
+
+import (
+	"testing"
+)
+
+type Doc struct {
+	Name  string
+	Tags  []string
+	Attrs map[string]int
+	Next  *Doc
+}
+
+var (
+	docA = Doc{Name: "a", Tags: []string{"x", "y", "z"}, Attrs: map[string]int{"k": 1, "long key": 2}, Next: &Doc{Name: "n"}}
+	docB = Doc{Name: "b", Tags: []string{"x", "new", "y", "z"}, Attrs: map[string]int{"long key": 3}, Next: &Doc{Name: "n"}}
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	want := `@@ -1,7 +1,7 @@
+ pretty.Doc{
+-    Name:  "a",
++    Name:  "b",
+-    Tags:  {"x", "y", "z"},
++    Tags:  {"x", "new", "y", "z"},
+-    Attrs: {"k":1, "long key":2},
++    Attrs: {"long key":3},
+     Next:  &pretty.Doc{
+         Name:  "n",
+         Tags:  nil,`
+	if got := UnifiedDiff(docA, docB); got != want {
+		t.Errorf("UnifiedDiff:\n%s\nwant:\n%s", got, want)
+	}
+	if got := UnifiedDiff(docA, docA); got != "" {
+		t.Errorf("UnifiedDiff of equal values = %q want \"\"", got)
+	}
+}
+
+func TestSideBySideDiff(t *testing.T) {
+	want := `pretty.Doc{                          pretty.Doc{
+    Name:  "a",                    |     Name:  "b",
+    Tags:  {"x", "y", "z"},        |     Tags:  {"x", "new", "y", "z"},
+    Attrs: {"k":1, "long key":2},  |     Attrs: {"long key":3},
+    Next:  &pretty.Doc{                  Next:  &pretty.Doc{
+        Name:  "n",                          Name:  "n",
+        Tags:  nil,                          Tags:  nil,
+        Attrs: {},                           Attrs: {},
+        Next:  (*pretty.Doc)(nil),           Next:  (*pretty.Doc)(nil),
+    },                                   },
+}                                    }`
+	if got := SideBySideDiff(docA, docB); got != want {
+		t.Errorf("SideBySideDiff:\n%s\nwant:\n%s", got, want)
+	}
+	want = `[]interface {}{   []interface {}{
+    "a",              "a",
+    "b",        |     "d",
+    "c",        <
+}                 }`
+	if got := SideBySideDiff([]interface{}{"a", "b", "c"}, []interface{}{"a", "d"}); got != want {
+		t.Errorf("SideBySideDiff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLineKey(t *testing.T) {
+	for _, tt := range []struct{ line, key string }{
+		{`    Name:  "a",`, `    Name:`},
+		{`        "a:b": 1,`, `        "a:b":`},
+		{`    "x",`, `    "x",`},
+		{`}`, `}`},
+		{`    -1:    "neg",`, `    -1:`},
+		{`    pkg.T{A:1}: 2,`, `    pkg.T{A:1}: 2,`},
+	} {
+		if key := lineKey(tt.line); key != tt.key {
+			t.Errorf("lineKey(%q) = %q want %q", tt.line, key, tt.key)
+		}
+	}
+}